@@ -0,0 +1,418 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/monooso/gitboard/export"
+)
+
+// File is the contents of a gitboard config file. Its top-level settings
+// apply to every profile; a named profile overrides them.
+type File struct {
+	Profile
+
+	// DefaultProfile is used when no profile is chosen on the command line
+	// or in the GITBOARD_PROFILE environment variable.
+	DefaultProfile string `json:"default_profile,omitempty"`
+
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+}
+
+// Profile is a named set of settings. Every field is optional; empty fields
+// fall through to the next source in order of precedence.
+type Profile struct {
+	// Each token can be given directly, read from an environment variable,
+	// or read from a file. If more than one is set, they are tried in that
+	// order.
+	GitHubToken       string `json:"github_token,omitempty"`
+	GitHubTokenEnv    string `json:"github_token_env,omitempty"`
+	GitHubTokenFile   string `json:"github_token_file,omitempty"`
+	PinboardToken     string `json:"pinboard_token,omitempty"`
+	PinboardTokenEnv  string `json:"pinboard_token_env,omitempty"`
+	PinboardTokenFile string `json:"pinboard_token_file,omitempty"`
+
+	// GitHubUser exports that user's public stars instead of your own.
+	GitHubUser string `json:"github_user,omitempty"`
+
+	// GitHubAPI is "rest" or "graphql", choosing how stars are fetched.
+	GitHubAPI string `json:"github_api,omitempty"`
+
+	// StarLists turns GitHub star lists into tags and makes them available
+	// to filters. It is a pointer so that false can override true.
+	StarLists *bool `json:"star_lists,omitempty"`
+
+	TagRules            *export.TagRules `json:"tag_rules,omitempty"`
+	Filter              Filter           `json:"filter,omitzero"`
+	TitleTemplate       string           `json:"title_template,omitempty"`
+	DescriptionTemplate string           `json:"description_template,omitempty"`
+
+	// Privacy is "private" or "public".
+	Privacy string `json:"privacy,omitempty"`
+}
+
+// Filter is the config file form of export.Filter. Name patterns are regular
+// expressions, and dates are YYYY-MM-DD or RFC 3339.
+type Filter struct {
+	IncludeOwners []string `json:"include_owners,omitempty"`
+	ExcludeOwners []string `json:"exclude_owners,omitempty"`
+	IncludeTopics []string `json:"include_topics,omitempty"`
+	ExcludeTopics []string `json:"exclude_topics,omitempty"`
+	IncludeLists  []string `json:"include_lists,omitempty"`
+	ExcludeLists  []string `json:"exclude_lists,omitempty"`
+
+	IncludeLanguages []string `json:"include_languages,omitempty"`
+	ExcludeLanguages []string `json:"exclude_languages,omitempty"`
+
+	// These are pointers so that a flag can switch them off again.
+	ExcludeArchived *bool `json:"exclude_archived,omitempty"`
+	ExcludeForks    *bool `json:"exclude_forks,omitempty"`
+	MinStars        *int  `json:"min_stars,omitempty"`
+
+	IncludeName   string `json:"include_name,omitempty"`
+	ExcludeName   string `json:"exclude_name,omitempty"`
+	StarredAfter  string `json:"starred_after,omitempty"`
+	StarredBefore string `json:"starred_before,omitempty"`
+}
+
+// Settings are the fully resolved settings for a run.
+type Settings struct {
+	// Profile is the name of the selected profile, or empty if none was.
+	Profile string
+
+	GitHubToken         string
+	PinboardToken       string
+	GitHubUser          string
+	GraphQL             bool
+	StarLists           bool
+	TagRules            export.TagRules
+	Filter              export.Filter
+	TitleTemplate       string
+	DescriptionTemplate string
+	Privacy             export.Privacy
+}
+
+// DefaultPath returns the default location of the config file, following the
+// XDG base directory specification: $XDG_CONFIG_HOME/gitboard/config.json,
+// falling back to ~/.config/gitboard/config.json.
+func DefaultPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gitboard", "config.json"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".config", "gitboard", "config.json"), nil
+}
+
+// Load reads the config file at path. The returned error wraps
+// os.ErrNotExist if the file does not exist.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	return &f, nil
+}
+
+// Resolve works out the settings for a run. Each setting is taken from the
+// first of these that provides it:
+//
+//  1. the command line, given as cli
+//  2. the GITHUB_TOKEN and PINBOARD_TOKEN environment variables (tokens only)
+//  3. the selected profile
+//  4. the top level of the config file
+//  5. the built-in default
+//
+// The profile is chosen by name, falling back to the GITBOARD_PROFILE
+// environment variable and then the file's default_profile. f may be nil if
+// there is no config file. getenv looks up environment variables.
+func Resolve(f *File, name string, cli Profile, getenv func(string) string) (Settings, error) {
+	if f == nil {
+		f = &File{}
+	}
+
+	if name == "" {
+		name = getenv("GITBOARD_PROFILE")
+	}
+	if name == "" {
+		name = f.DefaultProfile
+	}
+
+	var profile Profile
+	if name != "" {
+		p, ok := f.Profiles[name]
+		if !ok {
+			return Settings{}, fmt.Errorf("unknown profile %q", name)
+		}
+		profile = p
+	}
+
+	env := Profile{
+		GitHubToken:   getenv("GITHUB_TOKEN"),
+		PinboardToken: getenv("PINBOARD_TOKEN"),
+	}
+
+	// Layers from lowest to highest precedence.
+	layers := []Profile{f.Profile, profile, env, cli}
+
+	var merged Profile
+	for _, layer := range layers {
+		merged = merged.overlay(layer)
+	}
+
+	var err error
+	merged.GitHubToken, err = firstToken(layers, getenv, func(p Profile) (string, string, string) {
+		return p.GitHubToken, p.GitHubTokenEnv, p.GitHubTokenFile
+	})
+	if err != nil {
+		return Settings{}, fmt.Errorf("failed to read GitHub token: %w", err)
+	}
+	merged.PinboardToken, err = firstToken(layers, getenv, func(p Profile) (string, string, string) {
+		return p.PinboardToken, p.PinboardTokenEnv, p.PinboardTokenFile
+	})
+	if err != nil {
+		return Settings{}, fmt.Errorf("failed to read Pinboard token: %w", err)
+	}
+
+	s, err := merged.settings()
+	s.Profile = name
+	return s, err
+}
+
+// firstToken returns the token from the highest-precedence layer that
+// provides one, where sources returns a layer's literal token, environment
+// variable, and file. Layers below it are never read, so a broken token file
+// doesn't matter once a higher layer has supplied the token.
+func firstToken(layers []Profile, getenv func(string) string, sources func(Profile) (string, string, string)) (string, error) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		literal, env, file := sources(layers[i])
+		token, err := resolveToken(literal, env, file, getenv)
+		if err != nil || token != "" {
+			return token, err
+		}
+	}
+
+	return "", nil
+}
+
+// resolveToken returns the first token available from a literal value, an
+// environment variable, or a file.
+func resolveToken(literal, env, file string, getenv func(string) string) (string, error) {
+	if literal != "" {
+		return literal, nil
+	}
+	if env != "" {
+		if token := getenv(env); token != "" {
+			return token, nil
+		}
+	}
+	if file != "" {
+		path, err := expandHome(file)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	return "", nil
+}
+
+// expandHome replaces a leading ~/ in path with the user's home directory.
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+
+	return filepath.Join(home, rest), nil
+}
+
+// overlay returns p with every field that is set in top replaced.
+func (p Profile) overlay(top Profile) Profile {
+	p.GitHubToken = pick(top.GitHubToken, p.GitHubToken)
+	p.PinboardToken = pick(top.PinboardToken, p.PinboardToken)
+	p.GitHubUser = pick(top.GitHubUser, p.GitHubUser)
+	p.GitHubAPI = pick(top.GitHubAPI, p.GitHubAPI)
+	p.TitleTemplate = pick(top.TitleTemplate, p.TitleTemplate)
+	p.DescriptionTemplate = pick(top.DescriptionTemplate, p.DescriptionTemplate)
+	p.Privacy = pick(top.Privacy, p.Privacy)
+
+	p.StarLists = pickPtr(top.StarLists, p.StarLists)
+	p.TagRules = pickPtr(top.TagRules, p.TagRules)
+
+	p.Filter = p.Filter.overlay(top.Filter)
+
+	return p
+}
+
+// overlay returns f with every field that is set in top replaced.
+func (f Filter) overlay(top Filter) Filter {
+	f.IncludeOwners = pickList(top.IncludeOwners, f.IncludeOwners)
+	f.ExcludeOwners = pickList(top.ExcludeOwners, f.ExcludeOwners)
+	f.IncludeTopics = pickList(top.IncludeTopics, f.IncludeTopics)
+	f.ExcludeTopics = pickList(top.ExcludeTopics, f.ExcludeTopics)
+	f.IncludeLists = pickList(top.IncludeLists, f.IncludeLists)
+	f.ExcludeLists = pickList(top.ExcludeLists, f.ExcludeLists)
+	f.IncludeLanguages = pickList(top.IncludeLanguages, f.IncludeLanguages)
+	f.ExcludeLanguages = pickList(top.ExcludeLanguages, f.ExcludeLanguages)
+	f.ExcludeArchived = pickPtr(top.ExcludeArchived, f.ExcludeArchived)
+	f.ExcludeForks = pickPtr(top.ExcludeForks, f.ExcludeForks)
+	f.MinStars = pickPtr(top.MinStars, f.MinStars)
+	f.IncludeName = pick(top.IncludeName, f.IncludeName)
+	f.ExcludeName = pick(top.ExcludeName, f.ExcludeName)
+	f.StarredAfter = pick(top.StarredAfter, f.StarredAfter)
+	f.StarredBefore = pick(top.StarredBefore, f.StarredBefore)
+
+	return f
+}
+
+// pick returns top if it is set, otherwise fallback.
+func pick(top, fallback string) string {
+	if top != "" {
+		return top
+	}
+	return fallback
+}
+
+// pickList returns top if it is set, otherwise fallback.
+func pickList(top, fallback []string) []string {
+	if len(top) > 0 {
+		return top
+	}
+	return fallback
+}
+
+// pickPtr returns top if it is set, otherwise fallback.
+func pickPtr[T any](top, fallback *T) *T {
+	if top != nil {
+		return top
+	}
+	return fallback
+}
+
+// settings converts a fully merged profile into Settings, validating it on
+// the way.
+func (p Profile) settings() (Settings, error) {
+	s := Settings{
+		GitHubToken:         p.GitHubToken,
+		PinboardToken:       p.PinboardToken,
+		GitHubUser:          p.GitHubUser,
+		TitleTemplate:       p.TitleTemplate,
+		DescriptionTemplate: p.DescriptionTemplate,
+	}
+
+	if p.TagRules != nil {
+		s.TagRules = *p.TagRules
+	}
+
+	switch p.GitHubAPI {
+	case "", "rest":
+	case "graphql":
+		s.GraphQL = true
+	default:
+		return Settings{}, fmt.Errorf("unknown GitHub API %q (want rest or graphql)", p.GitHubAPI)
+	}
+
+	// Filtering by list is impossible without fetching the lists.
+	s.StarLists = (p.StarLists != nil && *p.StarLists) ||
+		len(p.Filter.IncludeLists) > 0 || len(p.Filter.ExcludeLists) > 0
+
+	var err error
+	if s.Filter, err = p.Filter.compile(); err != nil {
+		return Settings{}, err
+	}
+	if s.Privacy, err = export.ParsePrivacy(p.Privacy); err != nil {
+		return Settings{}, err
+	}
+
+	return s, nil
+}
+
+// compile converts the filter to an export.Filter.
+func (f Filter) compile() (export.Filter, error) {
+	filter := export.Filter{
+		IncludeOwners: f.IncludeOwners,
+		ExcludeOwners: f.ExcludeOwners,
+		IncludeTopics: f.IncludeTopics,
+		ExcludeTopics: f.ExcludeTopics,
+		IncludeLists:  f.IncludeLists,
+		ExcludeLists:  f.ExcludeLists,
+
+		IncludeLanguages: f.IncludeLanguages,
+		ExcludeLanguages: f.ExcludeLanguages,
+		ExcludeArchived:  f.ExcludeArchived != nil && *f.ExcludeArchived,
+		ExcludeForks:     f.ExcludeForks != nil && *f.ExcludeForks,
+	}
+	if f.MinStars != nil {
+		filter.MinStars = *f.MinStars
+	}
+
+	var err error
+	if filter.IncludeName, err = compilePattern("include_name", f.IncludeName); err != nil {
+		return filter, err
+	}
+	if filter.ExcludeName, err = compilePattern("exclude_name", f.ExcludeName); err != nil {
+		return filter, err
+	}
+	if filter.StarredAfter, err = parseDate("starred_after", f.StarredAfter); err != nil {
+		return filter, err
+	}
+	if filter.StarredBefore, err = parseDate("starred_before", f.StarredBefore); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// compilePattern compiles the named regular expression setting. An empty
+// pattern returns nil.
+func compilePattern(name, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return re, nil
+}
+
+// parseDate parses the named date setting, accepting either a plain date or
+// a full RFC 3339 timestamp. An empty value returns the zero time.
+func parseDate(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.DateOnly, time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid %s %q: use YYYY-MM-DD or RFC 3339", name, value)
+}
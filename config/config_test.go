@@ -0,0 +1,452 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monooso/gitboard/export"
+)
+
+// env returns a getenv function backed by the given map.
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+// writeFile writes content to a file in a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+// TestLoad verifies that a config file with profiles is parsed.
+func TestLoad(t *testing.T) {
+	path := writeFile(t, "config.json", `{
+		"pinboard_token": "base:token",
+		"default_profile": "work",
+		"tag_rules": {"prefix": "gh:"},
+		"profiles": {
+			"work": {
+				"github_token_env": "WORK_GITHUB_TOKEN",
+				"filter": {"include_owners": ["acme"]},
+				"privacy": "public"
+			}
+		}
+	}`)
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if f.PinboardToken != "base:token" {
+		t.Errorf("expected base Pinboard token, got %q", f.PinboardToken)
+	}
+	if f.DefaultProfile != "work" {
+		t.Errorf("expected default profile work, got %q", f.DefaultProfile)
+	}
+	if f.TagRules == nil || f.TagRules.Prefix != "gh:" {
+		t.Errorf("expected tag rules with prefix gh:, got %+v", f.TagRules)
+	}
+
+	work, ok := f.Profiles["work"]
+	if !ok {
+		t.Fatal("expected work profile")
+	}
+	if work.GitHubTokenEnv != "WORK_GITHUB_TOKEN" || work.Privacy != "public" {
+		t.Errorf("unexpected work profile %+v", work)
+	}
+	if len(work.Filter.IncludeOwners) != 1 || work.Filter.IncludeOwners[0] != "acme" {
+		t.Errorf("unexpected work filter %+v", work.Filter)
+	}
+}
+
+// TestLoadMissingFile verifies that a missing file is reported as such.
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "config.json"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+}
+
+// TestLoadInvalidJSON verifies that a malformed file is an error.
+func TestLoadInvalidJSON(t *testing.T) {
+	path := writeFile(t, "config.json", `{"profiles": [}`)
+
+	if _, err := Load(path); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+// TestDefaultPathUsesXDGConfigHome verifies that XDG_CONFIG_HOME is respected.
+func TestDefaultPathUsesXDGConfigHome(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg-config")
+
+	path, err := DefaultPath()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := filepath.Join("/tmp/xdg-config", "gitboard", "config.json")
+	if path != expected {
+		t.Errorf("expected %q, got %q", expected, path)
+	}
+}
+
+// TestResolvePrecedence verifies that each setting comes from the highest
+// precedence source that provides it: flag, environment, profile, file, default.
+func TestResolvePrecedence(t *testing.T) {
+	f := &File{
+		Profile: Profile{
+			GitHubToken:   "file-github",
+			PinboardToken: "file-pinboard",
+			TitleTemplate: "file-title",
+			Privacy:       "public",
+		},
+		Profiles: map[string]Profile{
+			"work": {
+				GitHubToken:   "profile-github",
+				PinboardToken: "profile-pinboard",
+				TitleTemplate: "profile-title",
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		profile  string
+		cli      Profile
+		env      map[string]string
+		github   string
+		pinboard string
+		title    string
+	}{
+		{
+			name:     "file only",
+			github:   "file-github",
+			pinboard: "file-pinboard",
+			title:    "file-title",
+		},
+		{
+			name:     "profile over file",
+			profile:  "work",
+			github:   "profile-github",
+			pinboard: "profile-pinboard",
+			title:    "profile-title",
+		},
+		{
+			name:     "environment over profile",
+			profile:  "work",
+			env:      map[string]string{"GITHUB_TOKEN": "env-github"},
+			github:   "env-github",
+			pinboard: "profile-pinboard",
+			title:    "profile-title",
+		},
+		{
+			name:     "flag over environment",
+			profile:  "work",
+			cli:      Profile{GitHubToken: "flag-github", TitleTemplate: "flag-title"},
+			env:      map[string]string{"GITHUB_TOKEN": "env-github", "PINBOARD_TOKEN": "env-pinboard"},
+			github:   "flag-github",
+			pinboard: "env-pinboard",
+			title:    "flag-title",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Resolve(f, tt.profile, tt.cli, env(tt.env))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if s.GitHubToken != tt.github {
+				t.Errorf("expected GitHub token %q, got %q", tt.github, s.GitHubToken)
+			}
+			if s.PinboardToken != tt.pinboard {
+				t.Errorf("expected Pinboard token %q, got %q", tt.pinboard, s.PinboardToken)
+			}
+			if s.TitleTemplate != tt.title {
+				t.Errorf("expected title template %q, got %q", tt.title, s.TitleTemplate)
+			}
+			// No layer above the file sets privacy, so it always falls through.
+			if s.Privacy != export.PrivacyPublic {
+				t.Errorf("expected public privacy, got %q", s.Privacy)
+			}
+		})
+	}
+}
+
+// TestResolveDefaults verifies the built-in defaults when nothing is configured.
+func TestResolveDefaults(t *testing.T) {
+	s, err := Resolve(nil, "", Profile{}, env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.GitHubToken != "" || s.PinboardToken != "" {
+		t.Errorf("expected no tokens, got %q and %q", s.GitHubToken, s.PinboardToken)
+	}
+	if s.Privacy != export.PrivacyPrivate {
+		t.Errorf("expected private by default, got %q", s.Privacy)
+	}
+	if s.TagRules.Marker() != export.MarkerTag {
+		t.Errorf("expected default marker tag, got %q", s.TagRules.Marker())
+	}
+}
+
+// TestResolveProfileSelection verifies that the profile comes from the
+// argument, then GITBOARD_PROFILE, then default_profile.
+func TestResolveProfileSelection(t *testing.T) {
+	f := &File{
+		DefaultProfile: "home",
+		Profiles: map[string]Profile{
+			"home": {TitleTemplate: "home"},
+			"work": {TitleTemplate: "work"},
+			"bot":  {TitleTemplate: "bot"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		profile  string
+		env      map[string]string
+		expected string
+	}{
+		{name: "default profile", expected: "home"},
+		{name: "environment", env: map[string]string{"GITBOARD_PROFILE": "bot"}, expected: "bot"},
+		{name: "argument", profile: "work", env: map[string]string{"GITBOARD_PROFILE": "bot"}, expected: "work"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Resolve(f, tt.profile, Profile{}, env(tt.env))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.TitleTemplate != tt.expected {
+				t.Errorf("expected profile %q, got %q", tt.expected, s.TitleTemplate)
+			}
+			if s.Profile != tt.expected {
+				t.Errorf("expected profile name %q, got %q", tt.expected, s.Profile)
+			}
+		})
+	}
+}
+
+// TestResolveUnknownProfile verifies that selecting a missing profile is an error.
+func TestResolveUnknownProfile(t *testing.T) {
+	if _, err := Resolve(nil, "work", Profile{}, env(nil)); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+// TestResolveTokenSources verifies that tokens can be read from a named
+// environment variable or a file.
+func TestResolveTokenSources(t *testing.T) {
+	tokenFile := writeFile(t, "pinboard-token", "user:abc123\n")
+	f := &File{
+		Profile: Profile{
+			GitHubTokenEnv:    "WORK_GITHUB_TOKEN",
+			PinboardTokenFile: tokenFile,
+		},
+	}
+
+	s, err := Resolve(f, "", Profile{}, env(map[string]string{"WORK_GITHUB_TOKEN": "gh-from-env"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.GitHubToken != "gh-from-env" {
+		t.Errorf("expected GitHub token from env, got %q", s.GitHubToken)
+	}
+	if s.PinboardToken != "user:abc123" {
+		t.Errorf("expected trimmed Pinboard token from file, got %q", s.PinboardToken)
+	}
+}
+
+// TestResolveTokenFileMissing verifies that an unreadable token file is an error.
+func TestResolveTokenFileMissing(t *testing.T) {
+	f := &File{Profile: Profile{GitHubTokenFile: filepath.Join(t.TempDir(), "missing")}}
+
+	if _, err := Resolve(f, "", Profile{}, env(nil)); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+// TestResolveTokenFileOverridden verifies that a token file is not read when
+// a higher-precedence source provides the token.
+func TestResolveTokenFileOverridden(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	f := &File{
+		Profile: Profile{GitHubTokenFile: missing},
+		Profiles: map[string]Profile{
+			"work": {PinboardTokenFile: missing},
+		},
+	}
+
+	tests := []struct {
+		name             string
+		cli              Profile
+		vars             map[string]string
+		profile          string
+		expectedGitHub   string
+		expectedPinboard string
+	}{
+		{
+			name:           "flag overrides file",
+			cli:            Profile{GitHubToken: "gh-from-flag"},
+			expectedGitHub: "gh-from-flag",
+		},
+		{
+			name:             "environment overrides file",
+			vars:             map[string]string{"GITHUB_TOKEN": "gh-from-env", "PINBOARD_TOKEN": "pb-from-env"},
+			expectedGitHub:   "gh-from-env",
+			expectedPinboard: "pb-from-env",
+		},
+		{
+			name:             "flag overrides profile file",
+			cli:              Profile{GitHubToken: "gh-from-flag", PinboardToken: "pb-from-flag"},
+			profile:          "work",
+			expectedGitHub:   "gh-from-flag",
+			expectedPinboard: "pb-from-flag",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Resolve(f, tt.profile, tt.cli, env(tt.vars))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.GitHubToken != tt.expectedGitHub {
+				t.Errorf("expected GitHub token %q, got %q", tt.expectedGitHub, s.GitHubToken)
+			}
+			if s.PinboardToken != tt.expectedPinboard {
+				t.Errorf("expected Pinboard token %q, got %q", tt.expectedPinboard, s.PinboardToken)
+			}
+		})
+	}
+}
+
+// TestResolveFilter verifies that filter settings are overridden field by
+// field and compiled.
+func TestResolveFilter(t *testing.T) {
+	yes, no, stars := true, false, 50
+	f := &File{
+		Profile: Profile{
+			Filter: Filter{
+				ExcludeArchived:  &yes,
+				ExcludeForks:     &yes,
+				MinStars:         &stars,
+				IncludeLanguages: []string{"go"},
+				IncludeOwners:    []string{"golang"},
+				ExcludeName:      "^golang/x",
+				StarredAfter:     "2024-01-01",
+			},
+		},
+	}
+	cli := Profile{Filter: Filter{IncludeOwners: []string{"acme"}, ExcludeForks: &no}}
+
+	s, err := Resolve(f, "", cli, env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(s.Filter.IncludeOwners) != 1 || s.Filter.IncludeOwners[0] != "acme" {
+		t.Errorf("expected include owners from flag, got %v", s.Filter.IncludeOwners)
+	}
+	if s.Filter.ExcludeName == nil || !s.Filter.ExcludeName.MatchString("golang/x/tools") {
+		t.Errorf("expected exclude name pattern from file, got %v", s.Filter.ExcludeName)
+	}
+	if s.Filter.StarredAfter.IsZero() {
+		t.Error("expected starred after date from file")
+	}
+	if !s.Filter.ExcludeArchived || s.Filter.ExcludeForks {
+		t.Errorf("expected archived excluded by file and forks allowed by flag, got %+v", s.Filter)
+	}
+	if s.Filter.MinStars != 50 || len(s.Filter.IncludeLanguages) != 1 {
+		t.Errorf("expected min stars and languages from file, got %+v", s.Filter)
+	}
+}
+
+// TestResolveInvalidSettings verifies that bad values are rejected.
+func TestResolveInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name string
+		cli  Profile
+	}{
+		{name: "pattern", cli: Profile{Filter: Filter{IncludeName: "("}}},
+		{name: "date", cli: Profile{Filter: Filter{StarredBefore: "yesterday"}}},
+		{name: "privacy", cli: Profile{Privacy: "secret"}},
+		{name: "github api", cli: Profile{GitHubAPI: "soap"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Resolve(nil, "", tt.cli, env(nil)); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestResolveStarLists verifies that star lists can be switched on and off
+// by each layer, and are switched on by list filters.
+func TestResolveStarLists(t *testing.T) {
+	on, off := true, false
+
+	tests := []struct {
+		name     string
+		file     Profile
+		cli      Profile
+		expected bool
+	}{
+		{name: "default", expected: false},
+		{name: "file", file: Profile{StarLists: &on}, expected: true},
+		{name: "flag overrides file", file: Profile{StarLists: &on}, cli: Profile{StarLists: &off}, expected: false},
+		{name: "list filter", file: Profile{Filter: Filter{ExcludeLists: []string{"toys"}}}, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Resolve(&File{Profile: tt.file}, "", tt.cli, env(nil))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s.StarLists != tt.expected {
+				t.Errorf("expected StarLists %v, got %v", tt.expected, s.StarLists)
+			}
+			if tt.file.Filter.ExcludeLists != nil && len(s.Filter.ExcludeLists) != 1 {
+				t.Errorf("expected exclude lists to be passed through, got %v", s.Filter.ExcludeLists)
+			}
+		})
+	}
+}
+
+// TestResolveGitHubAPI verifies that the GitHub API can be chosen.
+func TestResolveGitHubAPI(t *testing.T) {
+	f := &File{Profile: Profile{GitHubAPI: "graphql"}}
+
+	s, err := Resolve(f, "", Profile{}, env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !s.GraphQL {
+		t.Error("expected GraphQL from the config file")
+	}
+
+	s, err = Resolve(f, "", Profile{GitHubAPI: "rest"}, env(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.GraphQL {
+		t.Error("expected the flag to select REST")
+	}
+}
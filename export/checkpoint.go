@@ -0,0 +1,81 @@
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/monooso/gitboard/pinboard"
+	"github.com/monooso/gitboard/state"
+)
+
+// ErrNoCheckpoint is returned when resuming without a saved checkpoint.
+var ErrNoCheckpoint = errors.New("no checkpoint to resume from")
+
+// Task is a single planned change to Pinboard.
+type Task struct {
+	Action     Action            `json:"action"`
+	RepoName   string            `json:"repo_name,omitempty"`
+	RepoID     int64             `json:"repo_id,omitempty"`
+	URL        string            `json:"url"`
+	OldURL     string            `json:"old_url,omitempty"`
+	StarredAt  time.Time         `json:"starred_at,omitzero"`
+	Hash       string            `json:"hash,omitempty"`
+	Bookmark   pinboard.Bookmark `json:"bookmark,omitzero"`
+	TagChanges []TagChange       `json:"tag_changes,omitempty"`
+}
+
+// Checkpoint records the work planned for an export and how much of it has
+// been completed.
+type Checkpoint struct {
+	StartedAt  time.Time `json:"started_at"`
+	Total      int       `json:"total"`
+	Orphaned   []string  `json:"orphaned,omitempty"`
+	Duplicates []string  `json:"duplicates,omitempty"`
+	Tasks      []Task    `json:"tasks"`
+	Done       int       `json:"done"`
+}
+
+// LoadCheckpoint reads the checkpoint at path. It returns ErrNoCheckpoint if
+// the file does not exist.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	if path == "" {
+		return nil, ErrNoCheckpoint
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoCheckpoint
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+
+	if cp.Done < 0 || cp.Done > len(cp.Tasks) {
+		return nil, fmt.Errorf("invalid checkpoint: %d of %d tasks done", cp.Done, len(cp.Tasks))
+	}
+
+	return &cp, nil
+}
+
+// Save writes the checkpoint to path.
+func (cp *Checkpoint) Save(path string) error {
+	return state.WriteJSON(path, cp)
+}
+
+// RemoveCheckpoint deletes the checkpoint at path. A missing file is not an
+// error.
+func RemoveCheckpoint(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+
+	return nil
+}
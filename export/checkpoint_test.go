@@ -0,0 +1,102 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monooso/gitboard/github"
+)
+
+// Test a failed export leaves a checkpoint that a later run can resume from.
+func TestRunResumeAfterFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	repos := []github.StarredRepo{
+		{FullName: "a/one", HTMLURL: "https://github.com/a/one"},
+		{FullName: "a/two", HTMLURL: "https://github.com/a/two"},
+		{FullName: "a/three", HTMLURL: "https://github.com/a/three"},
+	}
+
+	ghClient := &mockGitHubClient{repos: repos}
+	pbClient := &mockPinboardClient{failURL: "https://github.com/a/two"}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.CheckpointPath = path
+
+	if _, err := exporter.Run(context.Background()); err == nil {
+		t.Fatal("expected error from failed add, got nil")
+	}
+
+	cp, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("expected checkpoint to be saved: %v", err)
+	}
+	if cp.Done != 1 || len(cp.Tasks) != 3 {
+		t.Fatalf("expected 1 of 3 tasks done, got %d of %d", cp.Done, len(cp.Tasks))
+	}
+
+	// Resuming must not go back to GitHub or Pinboard for the lists.
+	ghClient.err = errors.New("GitHub should not be called")
+	pbClient.getErr = errors.New("Pinboard should not be listed")
+	pbClient.failURL = ""
+
+	var progressCalls []Progress
+	exporter.Resume = true
+	exporter.OnProgress = func(p Progress) {
+		progressCalls = append(progressCalls, p)
+	}
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error resuming: %v", err)
+	}
+
+	if result.Added != 3 || result.Total != 3 {
+		t.Errorf("expected Added=3 Total=3 including earlier work, got %+v", result)
+	}
+
+	added := pbClient.addedBookmarks
+	if len(added) != 3 || added[1].URL != "https://github.com/a/two" || added[2].URL != "https://github.com/a/three" {
+		t.Errorf("expected a/two and a/three to be added on resume, got %v", added)
+	}
+
+	if len(progressCalls) != 2 || progressCalls[0].Current != 2 || progressCalls[0].Total != 3 {
+		t.Errorf("expected progress to continue from 2/3, got %+v", progressCalls)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected checkpoint to be removed after completion, got %v", err)
+	}
+}
+
+// Test resuming without a checkpoint fails cleanly.
+func TestRunResumeWithoutCheckpoint(t *testing.T) {
+	exporter := NewExporter(&mockGitHubClient{}, &mockPinboardClient{})
+	exporter.CheckpointPath = filepath.Join(t.TempDir(), "checkpoint.json")
+	exporter.Resume = true
+
+	_, err := exporter.Run(context.Background())
+	if !errors.Is(err, ErrNoCheckpoint) {
+		t.Errorf("expected ErrNoCheckpoint, got %v", err)
+	}
+}
+
+// Test a dry run never writes a checkpoint.
+func TestRunDryRunWritesNoCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/one", HTMLURL: "https://github.com/a/one"},
+	}}
+	exporter := NewExporter(ghClient, &mockPinboardClient{})
+	exporter.CheckpointPath = path
+	exporter.DryRun = true
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no checkpoint in dry run, got %v", err)
+	}
+}
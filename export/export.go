@@ -2,10 +2,19 @@ package export
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/monooso/gitboard/github"
 	"github.com/monooso/gitboard/pinboard"
+	"github.com/monooso/gitboard/state"
 )
 
 // GitHubClient defines the interface for fetching starred repositories.
@@ -13,10 +22,160 @@ type GitHubClient interface {
 	GetStarredRepos(ctx context.Context) ([]github.StarredRepo, error)
 }
 
+// IncrementalGitHubClient is implemented by GitHub clients that can stop
+// fetching once they reach stars older than a given time.
+type IncrementalGitHubClient interface {
+	GetStarredReposSince(ctx context.Context, since time.Time) ([]github.StarredRepo, error)
+}
+
+// StarListGitHubClient is implemented by GitHub clients that can fetch the
+// user's star lists.
+type StarListGitHubClient interface {
+	GetStarLists(ctx context.Context) ([]github.StarList, error)
+}
+
 // PinboardClient defines the interface for interacting with Pinboard.
 type PinboardClient interface {
 	AddBookmark(ctx context.Context, b pinboard.Bookmark) error
+	DeleteBookmark(ctx context.Context, url string) error
+	GetBookmark(ctx context.Context, url string) (pinboard.Bookmark, error)
 	GetBookmarkURLsByTag(ctx context.Context, tag string) (map[string]bool, error)
+	GetBookmarksByTag(ctx context.Context, tag string) ([]pinboard.Bookmark, error)
+}
+
+// MarkerTag is the tag applied to every exported bookmark, unless the tag
+// rules say otherwise. It identifies the bookmarks gitboard manages.
+const MarkerTag = "github-repo"
+
+// UnstarredTag replaces the marker tag on bookmarks whose repository is no longer
+// starred, when pruning with PruneRetag.
+const UnstarredTag = "github-unstarred"
+
+// PrunePolicy determines how bookmarks for unstarred repositories are handled.
+type PrunePolicy string
+
+const (
+	// PruneOff leaves orphaned bookmarks alone. This is the default.
+	PruneOff PrunePolicy = ""
+	// PruneReport lists orphaned bookmarks without changing them.
+	PruneReport PrunePolicy = "report"
+	// PruneDelete deletes orphaned bookmarks.
+	PruneDelete PrunePolicy = "delete"
+	// PruneRetag replaces the marker tag with UnstarredTag on orphaned
+	// bookmarks.
+	PruneRetag PrunePolicy = "retag"
+)
+
+// ParsePrunePolicy converts a policy name to a PrunePolicy. An empty string
+// or "off" disables pruning.
+func ParsePrunePolicy(s string) (PrunePolicy, error) {
+	switch p := PrunePolicy(s); p {
+	case PruneReport, PruneDelete, PruneRetag:
+		return p, nil
+	case PruneOff, "off":
+		return PruneOff, nil
+	default:
+		return PruneOff, fmt.Errorf("unknown prune policy %q (want report, delete, or retag)", s)
+	}
+}
+
+// CheckPrune returns an error if pruning with policy could change bookmarks
+// that don't belong to the exported stars. When exporting another GitHub
+// user's stars under the default marker tag, the bookmarks for your own stars
+// carry the same tag, so deleting or retagging orphans would reach them too.
+func CheckPrune(policy PrunePolicy, githubUser string, rules TagRules) error {
+	if policy != PruneDelete && policy != PruneRetag {
+		return nil
+	}
+	if githubUser == "" || rules.Marker() != MarkerTag {
+		return nil
+	}
+	return fmt.Errorf("refusing to %s unstarred bookmarks for %s's stars under the default %q tag, which your own bookmarks share: set marker_tag in the tag rules", policy, githubUser, MarkerTag)
+}
+
+// Privacy determines whether new bookmarks are private or public.
+type Privacy string
+
+const (
+	// PrivacyPrivate creates private bookmarks. This is the default.
+	PrivacyPrivate Privacy = ""
+	// PrivacyPublic creates public bookmarks.
+	PrivacyPublic Privacy = "public"
+)
+
+// ParsePrivacy converts a privacy name to a Privacy. An empty string or
+// "private" selects PrivacyPrivate.
+func ParsePrivacy(s string) (Privacy, error) {
+	switch p := Privacy(s); p {
+	case PrivacyPublic:
+		return p, nil
+	case PrivacyPrivate, "private":
+		return PrivacyPrivate, nil
+	default:
+		return PrivacyPrivate, fmt.Errorf("unknown privacy %q (want private or public)", s)
+	}
+}
+
+// Order determines the order in which bookmarks are added.
+type Order string
+
+const (
+	// OrderNewestFirst adds the most recently starred repositories first.
+	// This is the default.
+	OrderNewestFirst Order = ""
+	// OrderOldestFirst adds the earliest starred repositories first.
+	OrderOldestFirst Order = "oldest"
+)
+
+// ParseOrder converts an order name to an Order. An empty string or
+// "newest" selects OrderNewestFirst.
+func ParseOrder(s string) (Order, error) {
+	switch o := Order(s); o {
+	case OrderOldestFirst:
+		return o, nil
+	case OrderNewestFirst, "newest":
+		return OrderNewestFirst, nil
+	default:
+		return OrderNewestFirst, fmt.Errorf("unknown order %q (want newest or oldest)", s)
+	}
+}
+
+// sortRepos sorts repos by the time they were starred, in the given order.
+// Repositories starred at the same time keep their relative order.
+func sortRepos(repos []github.StarredRepo, order Order) {
+	sort.SliceStable(repos, func(i, j int) bool {
+		if order == OrderOldestFirst {
+			return repos[i].StarredAt.Before(repos[j].StarredAt)
+		}
+		return repos[i].StarredAt.After(repos[j].StarredAt)
+	})
+}
+
+// Action describes what the exporter did, or would do in a dry run, with a
+// single item.
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionSkip   Action = "skip"
+	ActionUpdate Action = "update"
+	ActionReport Action = "report"
+	ActionDelete Action = "delete"
+	ActionRetag  Action = "retag"
+	ActionFilter Action = "filter"
+	ActionStar   Action = "star"
+	ActionMove   Action = "move"
+	ActionRename Action = "rename"
+)
+
+// changesPinboard reports whether the action writes to Pinboard.
+func (a Action) changesPinboard() bool {
+	switch a {
+	case ActionAdd, ActionUpdate, ActionDelete, ActionRetag, ActionMove, ActionRename:
+		return true
+	default:
+		return false
+	}
 }
 
 // Progress reports the current state of an export operation.
@@ -24,14 +183,36 @@ type Progress struct {
 	Current  int
 	Total    int
 	RepoName string
-	Skipped  bool
+	URL      string
+	Action   Action
+
+	// TagChanges lists any of the repository's tags that had to be altered
+	// to make them safe for Pinboard.
+	TagChanges []TagChange
 }
 
 // Result summarises a completed export operation.
 type Result struct {
-	Total   int
-	Added   int
-	Skipped int
+	Total    int
+	Added    int
+	Skipped  int
+	Updated  int
+	Removed  int
+	Retagged int
+	Filtered int
+	Moved    int
+
+	// Renamed counts bookmarks moved to the new URL of a repository that
+	// was renamed or transferred to another owner.
+	Renamed int
+
+	// Orphaned lists the URLs of bookmarks whose repository is no longer
+	// starred. It is only populated when pruning is enabled.
+	Orphaned []string
+
+	// Duplicates lists the URLs of bookmarks that were only recognised as a
+	// starred repository's by comparing canonical URLs. See CanonicalURL.
+	Duplicates []string
 }
 
 // Exporter exports GitHub starred repositories to Pinboard bookmarks.
@@ -39,7 +220,55 @@ type Exporter struct {
 	gh         GitHubClient
 	pb         PinboardClient
 	DryRun     bool
+	Update     bool
+	Prune      PrunePolicy
+	Merge      MergeStrategy
+	TagRules   TagRules
+	Filter     Filter
+	Privacy    Privacy
 	OnProgress func(Progress)
+
+	// TitleTemplate and DescriptionTemplate, if set, replace the default
+	// bookmark title and description. See ParseTemplate.
+	TitleTemplate       *template.Template
+	DescriptionTemplate *template.Template
+
+	// State records what has been exported. It is updated as the export
+	// progresses, but the caller is responsible for saving it.
+	State *state.Store
+
+	// Incremental enables incremental runs when State is set: only stars
+	// newer than the last run are fetched, and bookmarks whose hash matches
+	// State are skipped. Pinboard is still asked which bookmarks exist, so
+	// new stars aren't written over bookmarks made by hand. It has no effect
+	// in update or prune mode, which need the full picture, or when Filter
+	// has changed since the last run.
+	Incremental bool
+
+	// CheckpointPath is where the planned work and progress through it are
+	// saved, so a failed export can be resumed.
+	CheckpointPath string
+
+	// Resume continues the export saved at CheckpointPath rather than
+	// planning a new one.
+	Resume bool
+
+	// Order sets the order in which repositories are exported.
+	Order Order
+
+	// URLStrategy chooses the URL each repository is bookmarked under.
+	// Repositories already bookmarked under another strategy's URL are
+	// left there, unless MigrateURLs is set.
+	URLStrategy URLStrategy
+
+	// MigrateURLs moves bookmarks made under another URL strategy to the
+	// URL chosen by URLStrategy.
+	MigrateURLs bool
+
+	// StarLists fetches the user's star lists, so list membership can be
+	// turned into tags and used by Filter. The GitHub client must implement
+	// StarListGitHubClient.
+	StarLists bool
 }
 
 // NewExporter creates a new Exporter with the provided clients.
@@ -50,24 +279,33 @@ func NewExporter(gh GitHubClient, pb PinboardClient) *Exporter {
 	}
 }
 
-// NormaliseTags normalises topic tags by converting to lowercase and replacing spaces with hyphens.
-func NormaliseTags(topics []string) []string {
-	if len(topics) == 0 {
-		return []string{}
-	}
+// RepoToBookmark converts a GitHub starred repository to a Pinboard bookmark
+// using the default tag rules.
+func RepoToBookmark(repo github.StarredRepo) pinboard.Bookmark {
+	bookmark, _ := RepoToBookmarkWithRules(repo, TagRules{})
+	return bookmark
+}
 
-	normalised := make([]string, len(topics))
-	for i, topic := range topics {
-		normalised[i] = strings.ToLower(strings.ReplaceAll(topic, " ", "-"))
+// RepoToBookmarkWithRules converts a GitHub starred repository to a Pinboard
+// bookmark, applying the given tag rules to its topics and star lists. The
+// tags are then sanitised for Pinboard, and any that had to change are
+// returned.
+func RepoToBookmarkWithRules(repo github.StarredRepo, rules TagRules) (pinboard.Bookmark, []TagChange) {
+	lists := ListTags(repo.Lists, rules)
+
+	// List tags come first, so topics make way for them under MaxTags.
+	topicRules := rules
+	if rules.MaxTags > 0 {
+		topicRules.MaxTags = max(1, rules.MaxTags-len(lists))
 	}
-	return normalised
-}
 
-// RepoToBookmark converts a GitHub starred repository to a Pinboard bookmark.
-func RepoToBookmark(repo github.StarredRepo) pinboard.Bookmark {
-	tags := []string{"github-repo"}
-	normalisedTopics := NormaliseTags(repo.Topics)
-	tags = append(tags, normalisedTopics...)
+	tags := []string{rules.Marker()}
+	tags = append(tags, lists...)
+	tags = append(tags, ApplyTagRules(NormaliseTags(RepoTopics(repo, rules)), topicRules)...)
+	if rules.MaxTags > 0 && len(tags) > rules.MaxTags {
+		tags = tags[:rules.MaxTags]
+	}
+	tags, changes := SanitiseTags(tags)
 
 	return pinboard.Bookmark{
 		URL:         repo.HTMLURL,
@@ -76,51 +314,616 @@ func RepoToBookmark(repo github.StarredRepo) pinboard.Bookmark {
 		Tags:        tags,
 		Private:     true,
 		ToRead:      false,
-	}
+		Time:        repo.StarredAt,
+	}, changes
 }
 
 // Run fetches starred repositories and creates Pinboard bookmarks for any
-// that don't already exist. It returns a Result summarising what happened.
+// that don't already exist. In update mode, existing bookmarks whose title,
+// description, or tags no longer match the repository are rewritten. If
+// pruning is enabled, it then handles bookmarks whose repository is no longer
+// starred. It returns a Result summarising what happened.
+//
+// When CheckpointPath is set, the planned work is saved there as it is
+// carried out. If Resume is set, Run continues from that checkpoint instead
+// of fetching anything from GitHub or Pinboard.
 func (e *Exporter) Run(ctx context.Context) (Result, error) {
-	repos, err := e.gh.GetStarredRepos(ctx)
+	var cp *Checkpoint
+	var err error
+
+	if e.Resume {
+		cp, err = LoadCheckpoint(e.CheckpointPath)
+	} else {
+		cp, err = e.plan(ctx)
+	}
 	if err != nil {
 		return Result{}, err
 	}
 
-	// Fetch existing Pinboard bookmarks tagged "github-repo" for deduplication.
-	existing, err := e.pb.GetBookmarkURLsByTag(ctx, "github-repo")
+	return e.execute(ctx, cp)
+}
+
+// plan fetches starred repositories and existing bookmarks, and works out
+// what needs to be done with each of them.
+func (e *Exporter) plan(ctx context.Context) (*Checkpoint, error) {
+	startedAt := time.Now()
+	incremental := e.incremental()
+
+	repos, err := e.fetchRepos(ctx, incremental)
 	if err != nil {
-		return Result{}, err
+		return nil, err
 	}
+	sortRepos(repos, e.Order)
 
-	total := len(repos)
-	var added, skipped int
+	existing, bookmarks, err := e.fetchExisting(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &Checkpoint{StartedAt: startedAt, Total: len(repos)}
+	if e.Prune != PruneOff {
+		cp.Orphaned = findOrphans(repos, existing)
+	}
+	cp.Duplicates = findDuplicates(repos, existing)
+	variants := urlVariants(existing)
+	exported := e.exportedRepos()
+	movedFrom := make(map[string]bool)
 
-	for i, repo := range repos {
-		bookmark := RepoToBookmark(repo)
-		isSkipped := existing[bookmark.URL]
+	// Repositories can share a homepage, but each needs its own bookmark.
+	planned := make(map[string]bool, len(repos))
 
-		if e.OnProgress != nil {
-			e.OnProgress(Progress{
-				Current:  i + 1,
-				Total:    total,
+	for _, repo := range repos {
+		if !e.Filter.Match(repo) {
+			cp.Tasks = append(cp.Tasks, Task{
+				Action:   ActionFilter,
 				RepoName: repo.FullName,
-				Skipped:  isSkipped,
+				URL:      repo.HTMLURL,
 			})
+			continue
 		}
 
-		if isSkipped {
-			skipped++
-			continue
+		bookmark, changes, err := e.bookmarkFor(repo)
+		if err != nil {
+			return nil, err
+		}
+		if planned[bookmark.URL] {
+			bookmark.URL = repo.HTMLURL
+		}
+		planned[bookmark.URL] = true
+		hash := BookmarkHash(bookmark)
+
+		action := ActionAdd
+		oldURL := ""
+		if incremental && e.exportedUnchanged(bookmark.URL, hash) {
+			action = ActionSkip
+		} else if existing[bookmark.URL] {
+			action = ActionSkip
+
+			if current, ok := bookmarks[bookmark.URL]; ok && e.Merge != MergeNever {
+				desired := bookmark
+				if e.Merge == MergeMerge {
+					desired = MergeBookmark(current, bookmark, e.generatedTags(bookmark.URL))
+				}
+
+				if NeedsUpdate(current, desired) {
+					action = ActionUpdate
+					// Leave the visibility and read status as the user set them.
+					bookmark.Private = current.Private
+					bookmark.ToRead = current.ToRead
+				}
+			}
+		} else if alt := alternateURL(repo, bookmark.URL, variants); alt != "" {
+			// Bookmarked under another URL strategy or spelling: move it,
+			// or leave it where it is rather than create a duplicate.
+			action = ActionSkip
+			if e.MigrateURLs {
+				action = ActionMove
+				oldURL = alt
+			} else {
+				bookmark.URL = alt
+			}
+		}
+
+		// A repository renamed or transferred since it was exported keeps
+		// its ID, so follow it rather than create a duplicate.
+		if old := exported[repo.ID]; action == ActionAdd && old != "" && old != bookmark.URL && existing[old] {
+			action = ActionRename
+			oldURL = old
+		}
+		if oldURL != "" {
+			movedFrom[oldURL] = true
+		}
+
+		cp.Tasks = append(cp.Tasks, Task{
+			Action:     action,
+			RepoName:   repo.FullName,
+			RepoID:     repo.ID,
+			URL:        bookmark.URL,
+			OldURL:     oldURL,
+			StarredAt:  repo.StarredAt,
+			Hash:       hash,
+			Bookmark:   bookmark,
+			TagChanges: changes,
+		})
+	}
+
+	// Bookmarks being moved aren't orphans, even though their URL no longer
+	// belongs to a starred repository.
+	cp.Orphaned = slices.DeleteFunc(cp.Orphaned, func(url string) bool { return movedFrom[url] })
+
+	for _, url := range cp.Orphaned {
+		cp.Tasks = append(cp.Tasks, Task{Action: pruneAction(e.Prune), URL: url})
+	}
+
+	return cp, nil
+}
+
+// bookmarkFor builds the bookmark for repo, applying the exporter's tag rules
+// and templates.
+func (e *Exporter) bookmarkFor(repo github.StarredRepo) (pinboard.Bookmark, []TagChange, error) {
+	bookmark, changes := RepoToBookmarkWithRules(repo, e.TagRules)
+	bookmark.Private = e.Privacy != PrivacyPublic
+
+	if e.TitleTemplate != nil {
+		title, err := ExecuteTemplate(e.TitleTemplate, repo)
+		if err != nil {
+			return bookmark, changes, err
 		}
+		bookmark.Title = title
+	}
+
+	if e.DescriptionTemplate != nil {
+		description, err := ExecuteTemplate(e.DescriptionTemplate, repo)
+		if err != nil {
+			return bookmark, changes, err
+		}
+		bookmark.Description = description
+	}
+
+	bookmark.URL = e.URLStrategy.BookmarkURL(repo)
+	if homepage := Homepage(repo); homepage != "" && e.URLStrategy == URLRepoWithHomepage {
+		bookmark.Description = strings.TrimSpace(bookmark.Description + "\n\nHomepage: " + homepage)
+	}
+
+	return bookmark, changes, nil
+}
+
+// execute carries out the outstanding tasks in cp, saving the checkpoint
+// after each change to Pinboard. The checkpoint is removed once every task
+// has been completed.
+func (e *Exporter) execute(ctx context.Context, cp *Checkpoint) (Result, error) {
+	result := Result{Total: cp.Total, Orphaned: cp.Orphaned, Duplicates: cp.Duplicates}
+	total := len(cp.Tasks)
+
+	// Tasks completed by an earlier run still count towards the result.
+	for _, task := range cp.Tasks[:cp.Done] {
+		result.count(task.Action)
+	}
+
+	if err := e.saveCheckpoint(cp); err != nil {
+		return result, err
+	}
+
+	for i := cp.Done; i < total; i++ {
+		task := cp.Tasks[i]
+
+		e.progress(Progress{
+			Current:    i + 1,
+			Total:      total,
+			RepoName:   task.RepoName,
+			URL:        task.URL,
+			Action:     task.Action,
+			TagChanges: task.TagChanges,
+		})
+
+		action, err := e.perform(ctx, task)
+		if err != nil {
+			return result, err
+		}
+		result.count(action)
+
+		// Remember what actually happened so a resumed run counts it correctly.
+		cp.Tasks[i].Action = action
+		cp.Done = i + 1
+		if action.changesPinboard() {
+			if err := e.saveCheckpoint(cp); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if e.DryRun {
+		return result, nil
+	}
+
+	if e.State != nil {
+		e.State.LastRun = cp.StartedAt
+		e.State.Filter = e.Filter.Fingerprint()
+	}
+
+	if e.CheckpointPath != "" {
+		if err := RemoveCheckpoint(e.CheckpointPath); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
 
-		if !e.DryRun {
+// perform carries out a single task and returns the action actually taken,
+// which may differ from the planned one when the merge strategy finds there
+// is nothing to change. Pinboard is left untouched in a dry run.
+func (e *Exporter) perform(ctx context.Context, task Task) (Action, error) {
+	switch task.Action {
+	case ActionAdd, ActionUpdate:
+		if e.DryRun {
+			return task.Action, nil
+		}
+
+		bookmark, action, err := e.prepare(ctx, task)
+		if err != nil {
+			return task.Action, err
+		}
+		if action != ActionSkip {
 			if err := e.pb.AddBookmark(ctx, bookmark); err != nil {
-				return Result{Total: total, Added: added, Skipped: skipped}, err
+				return task.Action, err
+			}
+		}
+		e.record(task)
+		return action, nil
+	case ActionMove, ActionRename:
+		if e.DryRun {
+			return task.Action, nil
+		}
+		if err := e.move(ctx, task); err != nil {
+			return task.Action, err
+		}
+		e.record(task)
+		return task.Action, nil
+	case ActionSkip:
+		e.record(task)
+		return ActionSkip, nil
+	case ActionFilter:
+		return ActionFilter, nil
+	default:
+		return task.Action, e.prune(ctx, task.URL, task.Action)
+	}
+}
+
+// prepare returns the bookmark to write for an add or update task, applying
+// the merge strategy to whatever is currently on Pinboard. It returns
+// ActionSkip if the existing bookmark should be left alone.
+func (e *Exporter) prepare(ctx context.Context, task Task) (pinboard.Bookmark, Action, error) {
+	if e.Merge == MergeOverwrite {
+		return task.Bookmark, task.Action, nil
+	}
+
+	current, err := e.pb.GetBookmark(ctx, task.URL)
+	if errors.Is(err, pinboard.ErrNotFound) {
+		bookmark := task.Bookmark
+		if e.Merge == MergeMerge {
+			bookmark.Description = ManagedBlock(bookmark.Description)
+		}
+		return bookmark, ActionAdd, nil
+	}
+	if err != nil {
+		return pinboard.Bookmark{}, task.Action, err
+	}
+
+	if e.Merge == MergeNever {
+		return current, ActionSkip, nil
+	}
+
+	merged := MergeBookmark(current, task.Bookmark, e.generatedTags(task.URL))
+	if !NeedsUpdate(current, merged) {
+		return current, ActionSkip, nil
+	}
+
+	return merged, ActionUpdate, nil
+}
+
+// saveCheckpoint writes cp to CheckpointPath, if set. Nothing is written in a
+// dry run.
+func (e *Exporter) saveCheckpoint(cp *Checkpoint) error {
+	if e.CheckpointPath == "" || e.DryRun {
+		return nil
+	}
+
+	return cp.Save(e.CheckpointPath)
+}
+
+// count adds a completed action to the result totals.
+func (r *Result) count(action Action) {
+	switch action {
+	case ActionAdd:
+		r.Added++
+	case ActionUpdate:
+		r.Updated++
+	case ActionSkip:
+		r.Skipped++
+	case ActionDelete:
+		r.Removed++
+	case ActionRetag:
+		r.Retagged++
+	case ActionFilter:
+		r.Filtered++
+	case ActionMove:
+		r.Moved++
+	case ActionRename:
+		r.Renamed++
+	}
+}
+
+// incremental reports whether this run can rely on State instead of fetching
+// every star from GitHub. A changed filter may let through stars that were
+// left out before, so it needs a full run.
+func (e *Exporter) incremental() bool {
+	if !e.Incremental || e.State == nil || e.State.LastRun.IsZero() {
+		return false
+	}
+	if e.Update || e.Prune != PruneOff || e.MigrateURLs {
+		return false
+	}
+	if e.State.Filter != e.Filter.Fingerprint() {
+		return false
+	}
+
+	_, ok := e.gh.(IncrementalGitHubClient)
+	return ok
+}
+
+// fetchRepos fetches starred repositories from GitHub. Incremental runs only
+// fetch stars newer than the last run.
+// If StarLists is set, each repository's Lists are filled in.
+func (e *Exporter) fetchRepos(ctx context.Context, incremental bool) ([]github.StarredRepo, error) {
+	var repos []github.StarredRepo
+	var err error
+
+	if incremental {
+		repos, err = e.gh.(IncrementalGitHubClient).GetStarredReposSince(ctx, e.State.LastRun)
+	} else {
+		repos, err = e.gh.GetStarredRepos(ctx)
+	}
+	if err != nil || !e.StarLists {
+		return repos, err
+	}
+
+	lister, ok := e.gh.(StarListGitHubClient)
+	if !ok {
+		return nil, errors.New("GitHub client cannot fetch star lists")
+	}
+	lists, err := lister.GetStarLists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return AssignStarLists(repos, lists), nil
+}
+
+// AssignStarLists sets the Lists of each repository to the names of the star
+// lists containing it. Repositories are matched by full name, ignoring case.
+func AssignStarLists(repos []github.StarredRepo, lists []github.StarList) []github.StarredRepo {
+	membership := make(map[string][]string)
+	for _, list := range lists {
+		for _, name := range list.Repos {
+			key := strings.ToLower(name)
+			membership[key] = append(membership[key], list.Name)
+		}
+	}
+
+	for i := range repos {
+		repos[i].Lists = membership[strings.ToLower(repos[i].FullName)]
+	}
+	return repos
+}
+
+// record notes in State that the task's repository has been exported.
+// Nothing is recorded in a dry run.
+func (e *Exporter) record(task Task) {
+	if e.State == nil || e.DryRun {
+		return
+	}
+
+	e.State.Put(task.URL, state.Entry{
+		Name:      task.RepoName,
+		StarredAt: task.StarredAt,
+		Hash:      task.Hash,
+		RepoID:    task.RepoID,
+		Tags:      task.Bookmark.Tags,
+	})
+}
+
+// generatedTags returns the tags gitboard generated for the bookmark at url
+// when it was last exported, if State recorded them.
+func (e *Exporter) generatedTags(url string) []string {
+	if e.State == nil {
+		return nil
+	}
+
+	entry, _ := e.State.Get(url)
+	return entry.Tags
+}
+
+// exportedUnchanged reports whether State records the bookmark at url as
+// exported with the given hash.
+func (e *Exporter) exportedUnchanged(url, hash string) bool {
+	entry, ok := e.State.Get(url)
+	return ok && entry.Hash == hash
+}
+
+// exportedRepos maps the IDs of the repositories recorded in State to the
+// URLs they were exported under.
+func (e *Exporter) exportedRepos() map[int64]string {
+	if e.State == nil {
+		return nil
+	}
+
+	urls := make(map[int64]string, len(e.State.Entries))
+	for url, entry := range e.State.Entries {
+		if entry.RepoID == 0 {
+			continue
+		}
+		// Pick the same URL every time if an ID was somehow recorded twice.
+		if prev, ok := urls[entry.RepoID]; !ok || url < prev {
+			urls[entry.RepoID] = url
+		}
+	}
+	return urls
+}
+
+// BookmarkHash returns a digest of the parts of a bookmark that gitboard
+// generates. Tag order is ignored, matching NeedsUpdate.
+func BookmarkHash(b pinboard.Bookmark) string {
+	tags := append([]string(nil), b.Tags...)
+	sort.Strings(tags)
+
+	h := sha256.New()
+	for _, part := range []string{b.URL, b.Title, b.Description, strings.Join(tags, " ")} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fetchExisting fetches the Pinboard bookmarks tagged with the marker for
+// deduplication. It returns the set of bookmarked URLs and, in update mode,
+// the full bookmarks keyed by URL.
+func (e *Exporter) fetchExisting(ctx context.Context) (map[string]bool, map[string]pinboard.Bookmark, error) {
+	if !e.Update {
+		urls, err := e.pb.GetBookmarkURLsByTag(ctx, e.TagRules.Marker())
+		return urls, nil, err
+	}
+
+	list, err := e.pb.GetBookmarksByTag(ctx, e.TagRules.Marker())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	urls := make(map[string]bool, len(list))
+	bookmarks := make(map[string]pinboard.Bookmark, len(list))
+	for _, b := range list {
+		urls[b.URL] = true
+		bookmarks[b.URL] = b
+	}
+
+	return urls, bookmarks, nil
+}
+
+// NeedsUpdate reports whether an existing bookmark's title, description, or
+// tags differ from the generated one. Tag order is ignored.
+func NeedsUpdate(existing, generated pinboard.Bookmark) bool {
+	if existing.Title != generated.Title || existing.Description != generated.Description {
+		return true
+	}
+
+	return !sameTags(existing.Tags, generated.Tags)
+}
+
+// sameTags reports whether a and b contain the same set of tags.
+func sameTags(a, b []string) bool {
+	set := make(map[string]bool, len(a))
+	for _, tag := range a {
+		set[tag] = true
+	}
+
+	other := make(map[string]bool, len(b))
+	for _, tag := range b {
+		if !set[tag] {
+			return false
+		}
+		other[tag] = true
+	}
+
+	return len(set) == len(other)
+}
+
+// progress reports p to the OnProgress callback, if one is set.
+func (e *Exporter) progress(p Progress) {
+	if e.OnProgress != nil {
+		e.OnProgress(p)
+	}
+}
+
+// prune applies the given action to an orphaned bookmark. Nothing is changed
+// in a dry run.
+func (e *Exporter) prune(ctx context.Context, url string, action Action) error {
+	if e.DryRun {
+		return nil
+	}
+
+	// The bookmark no longer reflects a starred repo, so forget we exported it.
+	if e.State != nil && action != ActionReport {
+		e.State.Remove(url)
+	}
+
+	switch action {
+	case ActionDelete:
+		return e.pb.DeleteBookmark(ctx, url)
+	case ActionRetag:
+		bookmark, err := e.pb.GetBookmark(ctx, url)
+		if err != nil {
+			return err
+		}
+		bookmark.Tags = RetagUnstarred(bookmark.Tags, e.TagRules.Marker())
+		return e.pb.AddBookmark(ctx, bookmark)
+	}
+
+	return nil
+}
+
+// pruneAction maps a prune policy to the action taken for each orphan.
+func pruneAction(policy PrunePolicy) Action {
+	switch policy {
+	case PruneDelete:
+		return ActionDelete
+	case PruneRetag:
+		return ActionRetag
+	default:
+		return ActionReport
+	}
+}
+
+// findOrphans returns the sorted URLs of existing bookmarks that don't belong
+// to any of the given starred repositories, under any URL strategy or
+// spelling.
+func findOrphans(repos []github.StarredRepo, existing map[string]bool) []string {
+	starred := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		for _, url := range RepoURLs(repo) {
+			starred[CanonicalURL(url)] = true
+		}
+	}
+
+	var orphaned []string
+	for url := range existing {
+		if !starred[CanonicalURL(url)] {
+			orphaned = append(orphaned, url)
+		}
+	}
+	sort.Strings(orphaned)
+
+	return orphaned
+}
+
+// RetagUnstarred replaces the marker tag with UnstarredTag, leaving any other
+// tags untouched.
+func RetagUnstarred(tags []string, marker string) []string {
+	retagged := make([]string, 0, len(tags))
+	seen := false
+	for _, tag := range tags {
+		if tag == marker || tag == UnstarredTag {
+			if !seen {
+				retagged = append(retagged, UnstarredTag)
+				seen = true
 			}
+			continue
 		}
-		added++
+		retagged = append(retagged, tag)
+	}
+	if !seen {
+		retagged = append(retagged, UnstarredTag)
 	}
 
-	return Result{Total: total, Added: added, Skipped: skipped}, nil
+	return retagged
 }
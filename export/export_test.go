@@ -3,11 +3,22 @@ package export
 import (
 	"context"
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/monooso/gitboard/github"
 	"github.com/monooso/gitboard/pinboard"
+	"github.com/monooso/gitboard/state"
+)
+
+// Both GitHub clients must work with the exporter, including incremental
+// runs and star lists.
+var (
+	_ IncrementalGitHubClient = (*github.Client)(nil)
+	_ IncrementalGitHubClient = (*github.GraphQLClient)(nil)
+	_ StarListGitHubClient    = (*github.GraphQLClient)(nil)
+	_ GitHubClient            = (*github.GraphQLClient)(nil)
 )
 
 // Mock implementations for testing
@@ -15,28 +26,77 @@ import (
 type mockGitHubClient struct {
 	repos []github.StarredRepo
 	err   error
+	since time.Time
 }
 
 func (m *mockGitHubClient) GetStarredRepos(ctx context.Context) ([]github.StarredRepo, error) {
 	return m.repos, m.err
 }
 
+func (m *mockGitHubClient) GetStarredReposSince(ctx context.Context, since time.Time) ([]github.StarredRepo, error) {
+	m.since = since
+	var repos []github.StarredRepo
+	for _, repo := range m.repos {
+		if !repo.StarredAt.Before(since) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, m.err
+}
+
 type mockPinboardClient struct {
-	addedBookmarks []pinboard.Bookmark
-	existingURLs   map[string]bool
-	err            error
-	getErr         error
+	getCalls        int
+	addedBookmarks  []pinboard.Bookmark
+	deletedURLs     []string
+	existingURLs    map[string]bool
+	storedBookmarks map[string]pinboard.Bookmark
+	err             error
+	getErr          error
+	failURL         string
+	lastTag         string
 }
 
 func (m *mockPinboardClient) AddBookmark(ctx context.Context, b pinboard.Bookmark) error {
 	if m.err != nil {
 		return m.err
 	}
+	if b.URL == m.failURL {
+		return errors.New("add failed")
+	}
 	m.addedBookmarks = append(m.addedBookmarks, b)
 	return nil
 }
 
+func (m *mockPinboardClient) DeleteBookmark(ctx context.Context, url string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.deletedURLs = append(m.deletedURLs, url)
+	return nil
+}
+
+func (m *mockPinboardClient) GetBookmark(ctx context.Context, url string) (pinboard.Bookmark, error) {
+	b, ok := m.storedBookmarks[url]
+	if !ok {
+		return pinboard.Bookmark{}, pinboard.ErrNotFound
+	}
+	return b, nil
+}
+
+func (m *mockPinboardClient) GetBookmarksByTag(ctx context.Context, tag string) ([]pinboard.Bookmark, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	var bookmarks []pinboard.Bookmark
+	for _, b := range m.storedBookmarks {
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, nil
+}
+
 func (m *mockPinboardClient) GetBookmarkURLsByTag(ctx context.Context, tag string) (map[string]bool, error) {
+	m.getCalls++
+	m.lastTag = tag
 	if m.getErr != nil {
 		return nil, m.getErr
 	}
@@ -385,3 +445,695 @@ func TestRunGitHubError(t *testing.T) {
 		t.Errorf("expected error %v, got %v", expectedErr, err)
 	}
 }
+
+// Test Run leaves orphaned bookmarks alone when pruning is disabled.
+func TestRunPruneOff(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/starred", HTMLURL: "https://github.com/a/starred"},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{
+			"https://github.com/a/starred":   true,
+			"https://github.com/a/unstarred": true,
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Orphaned) != 0 {
+		t.Errorf("expected no orphans when pruning is off, got %v", result.Orphaned)
+	}
+	if len(pbClient.deletedURLs) != 0 {
+		t.Errorf("expected no deletions, got %v", pbClient.deletedURLs)
+	}
+}
+
+// Test Run reports orphaned bookmarks without touching them.
+func TestRunPruneReport(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/starred", HTMLURL: "https://github.com/a/starred"},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{
+			"https://github.com/a/starred": true,
+			"https://github.com/b/gone":    true,
+			"https://github.com/a/gone":    true,
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Prune = PruneReport
+
+	var progressCalls []Progress
+	exporter.OnProgress = func(p Progress) {
+		progressCalls = append(progressCalls, p)
+	}
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"https://github.com/a/gone", "https://github.com/b/gone"}
+	if len(result.Orphaned) != len(expected) {
+		t.Fatalf("expected %d orphans, got %v", len(expected), result.Orphaned)
+	}
+	for i, url := range expected {
+		if result.Orphaned[i] != url {
+			t.Errorf("expected orphan %q, got %q", url, result.Orphaned[i])
+		}
+	}
+
+	if result.Removed != 0 || result.Retagged != 0 {
+		t.Errorf("expected no changes, got Removed=%d Retagged=%d", result.Removed, result.Retagged)
+	}
+	if len(pbClient.deletedURLs) != 0 || len(pbClient.addedBookmarks) != 0 {
+		t.Error("expected Pinboard not to be modified")
+	}
+
+	if len(progressCalls) != 3 {
+		t.Fatalf("expected 3 progress calls, got %d", len(progressCalls))
+	}
+	last := progressCalls[2]
+	if last.Action != ActionReport || last.Current != 3 || last.Total != 3 {
+		t.Errorf("expected final progress report 3/3, got %s %d/%d", last.Action, last.Current, last.Total)
+	}
+}
+
+// Test Run deletes orphaned bookmarks.
+func TestRunPruneDelete(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/starred", HTMLURL: "https://github.com/a/starred"},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{
+			"https://github.com/a/starred": true,
+			"https://github.com/a/gone":    true,
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Prune = PruneDelete
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Removed != 1 {
+		t.Errorf("expected Removed=1, got %d", result.Removed)
+	}
+	if len(pbClient.deletedURLs) != 1 || pbClient.deletedURLs[0] != "https://github.com/a/gone" {
+		t.Errorf("expected a/gone to be deleted, got %v", pbClient.deletedURLs)
+	}
+}
+
+// Test Run counts but does not delete orphans in a dry run.
+func TestRunPruneDeleteDryRun(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{"https://github.com/a/gone": true},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Prune = PruneDelete
+	exporter.DryRun = true
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Removed != 1 {
+		t.Errorf("expected Removed=1, got %d", result.Removed)
+	}
+	if len(pbClient.deletedURLs) != 0 {
+		t.Errorf("expected no deletions in dry run, got %v", pbClient.deletedURLs)
+	}
+}
+
+// Test Run re-tags orphaned bookmarks, keeping their other details.
+func TestRunPruneRetag(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{"https://github.com/a/gone": true},
+		storedBookmarks: map[string]pinboard.Bookmark{
+			"https://github.com/a/gone": {
+				URL:         "https://github.com/a/gone",
+				Title:       "a/gone",
+				Description: "Gone but not forgotten",
+				Tags:        []string{"github-repo", "go", "favourite"},
+				Private:     true,
+			},
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Prune = PruneRetag
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Retagged != 1 {
+		t.Errorf("expected Retagged=1, got %d", result.Retagged)
+	}
+	if len(pbClient.addedBookmarks) != 1 {
+		t.Fatalf("expected 1 bookmark rewritten, got %d", len(pbClient.addedBookmarks))
+	}
+
+	b := pbClient.addedBookmarks[0]
+	if b.Title != "a/gone" || b.Description != "Gone but not forgotten" || !b.Private {
+		t.Errorf("expected bookmark details to be preserved, got %+v", b)
+	}
+	expectedTags := []string{"github-unstarred", "go", "favourite"}
+	if len(b.Tags) != len(expectedTags) {
+		t.Fatalf("expected tags %v, got %v", expectedTags, b.Tags)
+	}
+	for i, tag := range expectedTags {
+		if b.Tags[i] != tag {
+			t.Errorf("expected tag %q, got %q", tag, b.Tags[i])
+		}
+	}
+}
+
+// Test ParsePrunePolicy accepts known policies and rejects others.
+func TestParsePrunePolicy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected PrunePolicy
+		wantErr  bool
+	}{
+		{input: "", expected: PruneOff},
+		{input: "off", expected: PruneOff},
+		{input: "report", expected: PruneReport},
+		{input: "delete", expected: PruneDelete},
+		{input: "retag", expected: PruneRetag},
+		{input: "purge", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			policy, err := ParsePrunePolicy(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if policy != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, policy)
+			}
+		})
+	}
+}
+
+// Test CheckPrune refuses to delete or retag when mirroring another user's
+// stars under the shared default marker tag.
+func TestCheckPrune(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PrunePolicy
+		user    string
+		rules   TagRules
+		wantErr bool
+	}{
+		{name: "own stars", policy: PruneDelete},
+		{name: "mirror delete", policy: PruneDelete, user: "octocat", wantErr: true},
+		{name: "mirror retag", policy: PruneRetag, user: "octocat", wantErr: true},
+		{name: "mirror report", policy: PruneReport, user: "octocat"},
+		{name: "mirror without pruning", policy: PruneOff, user: "octocat"},
+		{name: "mirror with own marker", policy: PruneDelete, user: "octocat", rules: TagRules{MarkerTag: "octocat-star"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPrune(tt.policy, tt.user, tt.rules)
+			if tt.wantErr && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// Test ParsePrivacy accepts known policies and rejects others.
+func TestParsePrivacy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Privacy
+		wantErr  bool
+	}{
+		{input: "", expected: PrivacyPrivate},
+		{input: "private", expected: PrivacyPrivate},
+		{input: "public", expected: PrivacyPublic},
+		{input: "secret", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			privacy, err := ParsePrivacy(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if privacy != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, privacy)
+			}
+		})
+	}
+}
+
+// Test Run creates public bookmarks when the privacy policy is public.
+func TestRunPublic(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "golang/go", HTMLURL: "https://github.com/golang/go"},
+	}}
+	pbClient := &mockPinboardClient{}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Privacy = PrivacyPublic
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pbClient.addedBookmarks) != 1 {
+		t.Fatalf("expected 1 bookmark, got %d", len(pbClient.addedBookmarks))
+	}
+	if pbClient.addedBookmarks[0].Private {
+		t.Error("expected a public bookmark")
+	}
+}
+
+// Test ParseOrder accepts known orders and rejects others.
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Order
+		wantErr  bool
+	}{
+		{input: "", expected: OrderNewestFirst},
+		{input: "newest", expected: OrderNewestFirst},
+		{input: "oldest", expected: OrderOldestFirst},
+		{input: "random", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			order, err := ParseOrder(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if order != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, order)
+			}
+		})
+	}
+}
+
+// Test Run dates bookmarks with the star date and adds them in the chosen order.
+func TestRunOrder(t *testing.T) {
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	repos := []github.StarredRepo{
+		{FullName: "a/feb", HTMLURL: "https://github.com/a/feb", StarredAt: feb},
+		{FullName: "a/mar", HTMLURL: "https://github.com/a/mar", StarredAt: mar},
+		{FullName: "a/jan", HTMLURL: "https://github.com/a/jan", StarredAt: jan},
+	}
+
+	tests := []struct {
+		order    Order
+		expected []time.Time
+	}{
+		{order: OrderNewestFirst, expected: []time.Time{mar, feb, jan}},
+		{order: OrderOldestFirst, expected: []time.Time{jan, feb, mar}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			ghClient := &mockGitHubClient{repos: append([]github.StarredRepo(nil), repos...)}
+			pbClient := &mockPinboardClient{}
+			exporter := NewExporter(ghClient, pbClient)
+			exporter.Order = tt.order
+
+			if _, err := exporter.Run(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(pbClient.addedBookmarks) != len(tt.expected) {
+				t.Fatalf("expected %d bookmarks, got %d", len(tt.expected), len(pbClient.addedBookmarks))
+			}
+			for i, want := range tt.expected {
+				if got := pbClient.addedBookmarks[i].Time; !got.Equal(want) {
+					t.Errorf("bookmark %d: expected time %v, got %v", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+// Test Run rewrites existing bookmarks whose metadata has changed in update mode.
+func TestRunUpdate(t *testing.T) {
+	mockRepos := []github.StarredRepo{
+		{
+			FullName:    "a/changed",
+			HTMLURL:     "https://github.com/a/changed",
+			Description: "New description",
+			Topics:      []string{"go"},
+		},
+		{
+			FullName:    "a/unchanged",
+			HTMLURL:     "https://github.com/a/unchanged",
+			Description: "Same",
+			Topics:      []string{"cli", "go"},
+		},
+	}
+
+	ghClient := &mockGitHubClient{repos: mockRepos}
+	pbClient := &mockPinboardClient{
+		storedBookmarks: map[string]pinboard.Bookmark{
+			"https://github.com/a/changed": {
+				URL:         "https://github.com/a/changed",
+				Title:       "a/changed",
+				Description: "Old description",
+				Tags:        []string{"github-repo", "go"},
+				Private:     false,
+				ToRead:      true,
+			},
+			"https://github.com/a/unchanged": {
+				URL:         "https://github.com/a/unchanged",
+				Title:       "a/unchanged",
+				Description: "Same",
+				Tags:        []string{"go", "github-repo", "cli"},
+				Private:     true,
+			},
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Update = true
+
+	var actions []Action
+	exporter.OnProgress = func(p Progress) {
+		actions = append(actions, p.Action)
+	}
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Updated != 1 || result.Skipped != 1 || result.Added != 0 {
+		t.Errorf("expected Updated=1 Skipped=1 Added=0, got Updated=%d Skipped=%d Added=%d",
+			result.Updated, result.Skipped, result.Added)
+	}
+	if len(actions) != 2 || actions[0] != ActionUpdate || actions[1] != ActionSkip {
+		t.Errorf("expected actions [update skip], got %v", actions)
+	}
+
+	if len(pbClient.addedBookmarks) != 1 {
+		t.Fatalf("expected 1 bookmark rewritten, got %d", len(pbClient.addedBookmarks))
+	}
+	b := pbClient.addedBookmarks[0]
+	if b.Description != "New description" {
+		t.Errorf("expected new description, got %q", b.Description)
+	}
+	if b.Private || !b.ToRead {
+		t.Errorf("expected visibility and read status to be preserved, got %+v", b)
+	}
+}
+
+// Test Run only counts updates in a dry run.
+func TestRunUpdateDryRun(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/changed", HTMLURL: "https://github.com/a/changed", Description: "New"},
+	}}
+	pbClient := &mockPinboardClient{
+		storedBookmarks: map[string]pinboard.Bookmark{
+			"https://github.com/a/changed": {
+				URL:         "https://github.com/a/changed",
+				Title:       "a/changed",
+				Description: "Old",
+				Tags:        []string{"github-repo"},
+			},
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Update = true
+	exporter.DryRun = true
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Updated != 1 {
+		t.Errorf("expected Updated=1, got %d", result.Updated)
+	}
+	if len(pbClient.addedBookmarks) != 0 {
+		t.Errorf("expected no writes in dry run, got %d", len(pbClient.addedBookmarks))
+	}
+}
+
+// Test NeedsUpdate compares title, description, and tags.
+func TestNeedsUpdate(t *testing.T) {
+	base := pinboard.Bookmark{
+		URL:         "https://github.com/a/b",
+		Title:       "a/b",
+		Description: "desc",
+		Tags:        []string{"github-repo", "go"},
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(b *pinboard.Bookmark)
+		expected bool
+	}{
+		{name: "identical", modify: func(b *pinboard.Bookmark) {}, expected: false},
+		{name: "tag order", modify: func(b *pinboard.Bookmark) { b.Tags = []string{"go", "github-repo"} }, expected: false},
+		{name: "privacy only", modify: func(b *pinboard.Bookmark) { b.Private = true }, expected: false},
+		{name: "title", modify: func(b *pinboard.Bookmark) { b.Title = "c/d" }, expected: true},
+		{name: "description", modify: func(b *pinboard.Bookmark) { b.Description = "other" }, expected: true},
+		{name: "extra tag", modify: func(b *pinboard.Bookmark) { b.Tags = []string{"github-repo", "go", "cli"} }, expected: true},
+		{name: "missing tag", modify: func(b *pinboard.Bookmark) { b.Tags = []string{"github-repo"} }, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated := base
+			generated.Tags = append([]string(nil), base.Tags...)
+			tt.modify(&generated)
+
+			if got := NeedsUpdate(base, generated); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// newTestStore returns an empty state store backed by a temporary file.
+func newTestStore(t *testing.T) *state.Store {
+	t.Helper()
+	s, err := state.Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("failed to create state store: %v", err)
+	}
+	return s
+}
+
+// Test Run records exported repos in the state store.
+func TestRunRecordsState(t *testing.T) {
+	starredAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/new", HTMLURL: "https://github.com/a/new", StarredAt: starredAt},
+		{FullName: "a/existing", HTMLURL: "https://github.com/a/existing", StarredAt: starredAt},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{"https://github.com/a/existing": true},
+	}
+	store := newTestStore(t)
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.State = store
+	exporter.Incremental = true
+
+	before := time.Now()
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The first run has no LastRun, so it must be a full run.
+	if pbClient.getCalls != 1 {
+		t.Errorf("expected Pinboard to be queried on the first run, got %d calls", pbClient.getCalls)
+	}
+	if !ghClient.since.IsZero() {
+		t.Errorf("expected a full GitHub fetch on the first run, got since=%v", ghClient.since)
+	}
+
+	if store.LastRun.Before(before) {
+		t.Errorf("expected LastRun to be set, got %v", store.LastRun)
+	}
+	for _, repo := range ghClient.repos {
+		entry, ok := store.Get(repo.HTMLURL)
+		if !ok {
+			t.Fatalf("expected state entry for %s", repo.FullName)
+		}
+		if entry.Name != repo.FullName || !entry.StarredAt.Equal(starredAt) {
+			t.Errorf("unexpected entry %+v", entry)
+		}
+		if entry.Hash != BookmarkHash(RepoToBookmark(repo)) {
+			t.Errorf("expected hash of generated bookmark for %s", repo.FullName)
+		}
+	}
+}
+
+// Test Run only fetches new stars and skips unchanged bookmarks once state
+// exists.
+func TestRunIncremental(t *testing.T) {
+	lastRun := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	unchanged := github.StarredRepo{
+		FullName:  "a/unchanged",
+		HTMLURL:   "https://github.com/a/unchanged",
+		StarredAt: lastRun.Add(time.Hour),
+	}
+	old := github.StarredRepo{
+		FullName:  "a/old",
+		HTMLURL:   "https://github.com/a/old",
+		StarredAt: lastRun.Add(-time.Hour),
+	}
+	fresh := github.StarredRepo{
+		FullName:  "a/fresh",
+		HTMLURL:   "https://github.com/a/fresh",
+		StarredAt: lastRun.Add(2 * time.Hour),
+	}
+
+	store := newTestStore(t)
+	store.LastRun = lastRun
+	store.Put(unchanged.HTMLURL, state.Entry{
+		Name: unchanged.FullName,
+		Hash: BookmarkHash(RepoToBookmark(unchanged)),
+	})
+
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{fresh, unchanged, old}}
+	pbClient := &mockPinboardClient{}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.State = store
+	exporter.Incremental = true
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ghClient.since.Equal(lastRun) {
+		t.Errorf("expected stars since %v, got %v", lastRun, ghClient.since)
+	}
+	if pbClient.getCalls != 1 {
+		t.Errorf("expected existing bookmarks to be fetched once, got %d calls", pbClient.getCalls)
+	}
+
+	if result.Total != 2 || result.Added != 1 || result.Skipped != 1 {
+		t.Errorf("expected Total=2 Added=1 Skipped=1, got %+v", result)
+	}
+	if len(pbClient.addedBookmarks) != 1 || pbClient.addedBookmarks[0].URL != fresh.HTMLURL {
+		t.Errorf("expected only a/fresh to be added, got %v", pbClient.addedBookmarks)
+	}
+	if _, ok := store.Get(fresh.HTMLURL); !ok {
+		t.Error("expected a/fresh to be recorded in state")
+	}
+}
+
+// Test Run leaves a new star alone on an incremental run if it is already
+// bookmarked, rather than overwriting the bookmark.
+func TestRunIncrementalSkipsExisting(t *testing.T) {
+	lastRun := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/saved", HTMLURL: "https://github.com/a/saved", StarredAt: lastRun.Add(time.Hour)},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{"https://github.com/a/saved": true},
+	}
+	store := newTestStore(t)
+	store.LastRun = lastRun
+
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.State = store
+	exporter.Incremental = true
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ghClient.since.Equal(lastRun) {
+		t.Errorf("expected stars since %v, got %v", lastRun, ghClient.since)
+	}
+	if result.Added != 0 || result.Skipped != 1 {
+		t.Errorf("expected Added=0 Skipped=1, got %+v", result)
+	}
+	if len(pbClient.addedBookmarks) != 0 {
+		t.Errorf("expected no bookmarks written, got %v", pbClient.addedBookmarks)
+	}
+}
+
+// Test Run falls back to a full run when pruning, even with state.
+func TestRunIncrementalDisabledByPrune(t *testing.T) {
+	store := newTestStore(t)
+	store.LastRun = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	store.Put("https://github.com/a/gone", state.Entry{Name: "a/gone"})
+
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{"https://github.com/a/gone": true},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.State = store
+	exporter.Incremental = true
+	exporter.Prune = PruneDelete
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pbClient.getCalls != 1 {
+		t.Errorf("expected Pinboard to be queried, got %d calls", pbClient.getCalls)
+	}
+	if _, ok := store.Get("https://github.com/a/gone"); ok {
+		t.Error("expected pruned bookmark to be removed from state")
+	}
+}
+
+// Test Run leaves state alone in a dry run.
+func TestRunDryRunLeavesState(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/new", HTMLURL: "https://github.com/a/new"},
+	}}
+	store := newTestStore(t)
+	exporter := NewExporter(ghClient, &mockPinboardClient{})
+	exporter.State = store
+	exporter.DryRun = true
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !store.LastRun.IsZero() || len(store.Entries) != 0 {
+		t.Errorf("expected state to be untouched, got %+v", store)
+	}
+}
@@ -0,0 +1,190 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monooso/gitboard/github"
+)
+
+// Filter decides which starred repositories are exported. Each criterion is
+// optional; a repository must satisfy all the criteria that are set. The zero
+// value matches everything.
+type Filter struct {
+	// IncludeOwners and ExcludeOwners match the owner part of the full name,
+	// ignoring case.
+	IncludeOwners []string
+	ExcludeOwners []string
+
+	// IncludeTopics matches repositories with at least one of the given
+	// topics; ExcludeTopics rejects repositories with any of them. Topics
+	// are compared after normalisation.
+	IncludeTopics []string
+	ExcludeTopics []string
+
+	// IncludeLists matches repositories in at least one of the given star
+	// lists; ExcludeLists rejects repositories in any of them. List names
+	// are compared after normalisation, so "Dev Tools" matches dev-tools.
+	// They need the exporter's StarLists option.
+	IncludeLists []string
+	ExcludeLists []string
+
+	// IncludeLanguages and ExcludeLanguages match the primary language,
+	// ignoring case. Repositories with no language never match
+	// IncludeLanguages.
+	IncludeLanguages []string
+	ExcludeLanguages []string
+
+	// ExcludeArchived and ExcludeForks reject archived repositories and
+	// forks.
+	ExcludeArchived bool
+	ExcludeForks    bool
+
+	// MinStars rejects repositories with fewer stargazers.
+	MinStars int
+
+	// IncludeName and ExcludeName are matched against the full name.
+	IncludeName *regexp.Regexp
+	ExcludeName *regexp.Regexp
+
+	// StarredAfter and StarredBefore bound the time the repository was
+	// starred. Both are exclusive.
+	StarredAfter  time.Time
+	StarredBefore time.Time
+}
+
+// Match reports whether repo passes the filter.
+func (f Filter) Match(repo github.StarredRepo) bool {
+	owner, _, _ := strings.Cut(repo.FullName, "/")
+	if len(f.IncludeOwners) > 0 && !containsFold(f.IncludeOwners, owner) {
+		return false
+	}
+	if containsFold(f.ExcludeOwners, owner) {
+		return false
+	}
+
+	topics := NormaliseTags(repo.Topics)
+	if len(f.IncludeTopics) > 0 && !anyTopic(topics, f.IncludeTopics) {
+		return false
+	}
+	if anyTopic(topics, f.ExcludeTopics) {
+		return false
+	}
+
+	lists := NormaliseTags(repo.Lists)
+	if len(f.IncludeLists) > 0 && !anyTopic(lists, f.IncludeLists) {
+		return false
+	}
+	if anyTopic(lists, f.ExcludeLists) {
+		return false
+	}
+
+	if len(f.IncludeLanguages) > 0 && !containsFold(f.IncludeLanguages, repo.Language) {
+		return false
+	}
+	if repo.Language != "" && containsFold(f.ExcludeLanguages, repo.Language) {
+		return false
+	}
+
+	if f.ExcludeArchived && repo.Archived {
+		return false
+	}
+	if f.ExcludeForks && repo.Fork {
+		return false
+	}
+	if repo.Stargazers < f.MinStars {
+		return false
+	}
+
+	if f.IncludeName != nil && !f.IncludeName.MatchString(repo.FullName) {
+		return false
+	}
+	if f.ExcludeName != nil && f.ExcludeName.MatchString(repo.FullName) {
+		return false
+	}
+
+	if !f.StarredAfter.IsZero() && !repo.StarredAt.After(f.StarredAfter) {
+		return false
+	}
+	if !f.StarredBefore.IsZero() && !repo.StarredAt.Before(f.StarredBefore) {
+		return false
+	}
+
+	return true
+}
+
+// Fingerprint returns a digest of the filter's criteria, so that a change to
+// them can be noticed between runs. The zero value's fingerprint is empty.
+func (f Filter) Fingerprint() string {
+	if sum := f.digest(); sum != zeroFilterDigest {
+		return sum
+	}
+	return ""
+}
+
+// zeroFilterDigest is the digest of a filter that matches everything.
+var zeroFilterDigest = Filter{}.digest()
+
+// digest hashes every criterion of the filter.
+func (f Filter) digest() string {
+	parts := []string{
+		strings.Join(f.IncludeOwners, ","),
+		strings.Join(f.ExcludeOwners, ","),
+		strings.Join(f.IncludeTopics, ","),
+		strings.Join(f.ExcludeTopics, ","),
+		strings.Join(f.IncludeLists, ","),
+		strings.Join(f.ExcludeLists, ","),
+		strings.Join(f.IncludeLanguages, ","),
+		strings.Join(f.ExcludeLanguages, ","),
+		strconv.FormatBool(f.ExcludeArchived),
+		strconv.FormatBool(f.ExcludeForks),
+		strconv.Itoa(f.MinStars),
+		pattern(f.IncludeName),
+		pattern(f.ExcludeName),
+		f.StarredAfter.UTC().Format(time.RFC3339Nano),
+		f.StarredBefore.UTC().Format(time.RFC3339Nano),
+	}
+
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// pattern returns the source of re, or an empty string if it is nil.
+func pattern(re *regexp.Regexp) string {
+	if re == nil {
+		return ""
+	}
+	return re.String()
+}
+
+// containsFold reports whether list contains s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyTopic reports whether any of topics appears in wanted, after
+// normalising wanted the same way as topics. It works for list names too.
+func anyTopic(topics, wanted []string) bool {
+	for _, w := range NormaliseTags(wanted) {
+		for _, topic := range topics {
+			if topic == w {
+				return true
+			}
+		}
+	}
+	return false
+}
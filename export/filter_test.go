@@ -0,0 +1,225 @@
+package export
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/monooso/gitboard/github"
+)
+
+// Test Filter.Match applies each criterion.
+func TestFilterMatch(t *testing.T) {
+	repo := github.StarredRepo{
+		FullName:   "Golang/go",
+		HTMLURL:    "https://github.com/golang/go",
+		Topics:     []string{"Language", "compiler"},
+		Lists:      []string{"Dev Tools"},
+		Language:   "Go",
+		Stargazers: 120,
+		StarredAt:  time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected bool
+	}{
+		{name: "zero filter", filter: Filter{}, expected: true},
+		{name: "include owner", filter: Filter{IncludeOwners: []string{"golang"}}, expected: true},
+		{name: "include other owner", filter: Filter{IncludeOwners: []string{"rust-lang"}}, expected: false},
+		{name: "exclude owner", filter: Filter{ExcludeOwners: []string{"GOLANG"}}, expected: false},
+		{name: "include topic", filter: Filter{IncludeTopics: []string{"language", "cli"}}, expected: true},
+		{name: "include missing topic", filter: Filter{IncludeTopics: []string{"cli"}}, expected: false},
+		{name: "exclude topic", filter: Filter{ExcludeTopics: []string{"Compiler"}}, expected: false},
+		{name: "include list", filter: Filter{IncludeLists: []string{"dev-tools"}}, expected: true},
+		{name: "include missing list", filter: Filter{IncludeLists: []string{"toys"}}, expected: false},
+		{name: "exclude list", filter: Filter{ExcludeLists: []string{"Dev Tools"}}, expected: false},
+		{name: "include language", filter: Filter{IncludeLanguages: []string{"go", "rust"}}, expected: true},
+		{name: "include other language", filter: Filter{IncludeLanguages: []string{"rust"}}, expected: false},
+		{name: "exclude language", filter: Filter{ExcludeLanguages: []string{"GO"}}, expected: false},
+		{name: "exclude archived", filter: Filter{ExcludeArchived: true}, expected: true},
+		{name: "exclude forks", filter: Filter{ExcludeForks: true}, expected: true},
+		{name: "min stars", filter: Filter{MinStars: 100}, expected: true},
+		{name: "too few stars", filter: Filter{MinStars: 500}, expected: false},
+		{name: "include name", filter: Filter{IncludeName: regexp.MustCompile(`/go$`)}, expected: true},
+		{name: "include other name", filter: Filter{IncludeName: regexp.MustCompile(`^rust`)}, expected: false},
+		{name: "exclude name", filter: Filter{ExcludeName: regexp.MustCompile(`(?i)golang`)}, expected: false},
+		{
+			name:     "starred after",
+			filter:   Filter{StarredAfter: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			expected: true,
+		},
+		{
+			name:     "starred too early",
+			filter:   Filter{StarredAfter: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			expected: false,
+		},
+		{
+			name:     "starred before",
+			filter:   Filter{StarredBefore: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			expected: true,
+		},
+		{
+			name:     "starred too late",
+			filter:   Filter{StarredBefore: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			expected: false,
+		},
+		{
+			name: "all criteria must match",
+			filter: Filter{
+				IncludeOwners: []string{"golang"},
+				ExcludeTopics: []string{"compiler"},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(repo); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// Test Filter.Match handles archived repos, forks and repos with no language.
+func TestFilterMatchMetadata(t *testing.T) {
+	archived := github.StarredRepo{FullName: "a/old", Archived: true}
+	fork := github.StarredRepo{FullName: "a/fork", Fork: true}
+	unknown := github.StarredRepo{FullName: "a/unknown"}
+
+	if (Filter{ExcludeArchived: true}).Match(archived) {
+		t.Error("expected archived repo to be excluded")
+	}
+	if (Filter{ExcludeForks: true}).Match(fork) {
+		t.Error("expected fork to be excluded")
+	}
+	if (Filter{IncludeLanguages: []string{"go"}}).Match(unknown) {
+		t.Error("expected repo with no language to fail IncludeLanguages")
+	}
+	if !(Filter{ExcludeLanguages: []string{"go"}}).Match(unknown) {
+		t.Error("expected repo with no language to pass ExcludeLanguages")
+	}
+}
+
+// Test Run reports filtered repos separately from added and skipped ones.
+func TestRunFilter(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "keep/new", HTMLURL: "https://github.com/keep/new"},
+		{FullName: "keep/existing", HTMLURL: "https://github.com/keep/existing"},
+		{FullName: "drop/repo", HTMLURL: "https://github.com/drop/repo"},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{
+			"https://github.com/keep/existing": true,
+			"https://github.com/drop/repo":     true,
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Filter = Filter{ExcludeOwners: []string{"drop"}}
+	exporter.Prune = PruneDelete
+
+	var actions []Action
+	exporter.OnProgress = func(p Progress) {
+		actions = append(actions, p.Action)
+	}
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Total != 3 || result.Added != 1 || result.Skipped != 1 || result.Filtered != 1 {
+		t.Errorf("expected Total=3 Added=1 Skipped=1 Filtered=1, got %+v", result)
+	}
+	if len(actions) != 3 || actions[2] != ActionFilter {
+		t.Errorf("expected the last repo to be filtered, got %v", actions)
+	}
+
+	// A filtered repo is still starred, so its bookmark is not an orphan.
+	if len(result.Orphaned) != 0 || len(pbClient.deletedURLs) != 0 {
+		t.Errorf("expected filtered repo not to be pruned, got %v", result.Orphaned)
+	}
+}
+
+// Test Filter.Fingerprint is empty for the zero filter and changes with the
+// criteria.
+func TestFilterFingerprint(t *testing.T) {
+	if fp := (Filter{}).Fingerprint(); fp != "" {
+		t.Errorf("expected an empty fingerprint for the zero filter, got %q", fp)
+	}
+
+	topics := Filter{ExcludeTopics: []string{"go"}}
+	if topics.Fingerprint() == "" || topics.Fingerprint() != (Filter{ExcludeTopics: []string{"go"}}).Fingerprint() {
+		t.Error("expected equal filters to have the same non-empty fingerprint")
+	}
+
+	others := []Filter{
+		{ExcludeTopics: []string{"rust"}},
+		{IncludeTopics: []string{"go"}},
+		{ExcludeName: regexp.MustCompile("go")},
+		{StarredBefore: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{MinStars: 1},
+	}
+	for _, other := range others {
+		if other.Fingerprint() == topics.Fingerprint() {
+			t.Errorf("expected %+v to have a different fingerprint", other)
+		}
+	}
+}
+
+// Test Run makes a full run when the filter has changed since the last run,
+// so stars it used to leave out are exported, and stays incremental when it
+// hasn't.
+func TestRunIncrementalFilterChanged(t *testing.T) {
+	lastRun := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	excluded := github.StarredRepo{
+		FullName:  "a/excluded",
+		HTMLURL:   "https://github.com/a/excluded",
+		Topics:    []string{"go"},
+		StarredAt: lastRun.Add(-time.Hour),
+	}
+	oldFilter := Filter{ExcludeTopics: []string{"go"}}
+
+	tests := []struct {
+		name        string
+		filter      Filter
+		incremental bool
+	}{
+		{name: "relaxed", filter: Filter{}, incremental: false},
+		{name: "unchanged", filter: oldFilter, incremental: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t)
+			store.LastRun = lastRun
+			store.Filter = oldFilter.Fingerprint()
+
+			ghClient := &mockGitHubClient{repos: []github.StarredRepo{excluded}}
+			pbClient := &mockPinboardClient{}
+			exporter := NewExporter(ghClient, pbClient)
+			exporter.State = store
+			exporter.Incremental = true
+			exporter.Filter = tt.filter
+
+			result, err := exporter.Run(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := !ghClient.since.IsZero(); got != tt.incremental {
+				t.Errorf("expected incremental=%v, got since=%v", tt.incremental, ghClient.since)
+			}
+			if !tt.incremental && result.Added != 1 {
+				t.Errorf("expected the previously excluded star to be added, got %+v", result)
+			}
+			if store.Filter != tt.filter.Fingerprint() {
+				t.Errorf("expected the filter to be recorded, got %q", store.Filter)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/monooso/gitboard/pinboard"
+)
+
+// MergeStrategy determines how gitboard treats changes made by hand to
+// bookmarks it has to rewrite.
+type MergeStrategy string
+
+const (
+	// MergeOverwrite replaces existing bookmarks with the generated ones.
+	// This is the default.
+	MergeOverwrite MergeStrategy = ""
+	// MergeMerge keeps user-added tags and any notes outside the
+	// gitboard-managed block in the description.
+	MergeMerge MergeStrategy = "merge"
+	// MergeNever leaves existing bookmarks untouched.
+	MergeNever MergeStrategy = "never"
+)
+
+// The managed block delimits the part of a bookmark's description that
+// gitboard owns when merging. Anything outside it belongs to the user.
+const (
+	managedStart = "[gitboard]"
+	managedEnd   = "[/gitboard]"
+)
+
+// ParseMergeStrategy converts a strategy name to a MergeStrategy. An empty
+// string or "overwrite" selects MergeOverwrite.
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	switch m := MergeStrategy(s); m {
+	case MergeMerge, MergeNever:
+		return m, nil
+	case MergeOverwrite, "overwrite":
+		return MergeOverwrite, nil
+	default:
+		return MergeOverwrite, fmt.Errorf("unknown merge strategy %q (want overwrite, merge, or never)", s)
+	}
+}
+
+// ManagedBlock wraps a generated description in the gitboard-managed block.
+func ManagedBlock(description string) string {
+	return managedStart + "\n" + description + "\n" + managedEnd
+}
+
+// MergeBookmark combines an existing bookmark with a generated one. The
+// title comes from the generated bookmark; the tags are the generated ones
+// followed by those the user added; and the managed block in the description
+// is replaced, leaving the user's own notes intact. Privacy and read status
+// are kept from the existing bookmark.
+//
+// previous lists the tags gitboard generated for the bookmark last time.
+// Those that are no longer generated, such as a topic removed from the
+// repository, are dropped. Any other existing tag is taken to be the user's.
+//
+// A description without a managed block is treated as the user's own notes
+// unless it matches the generated text, so nothing written by hand is lost.
+func MergeBookmark(existing, generated pinboard.Bookmark, previous []string) pinboard.Bookmark {
+	merged := generated
+	merged.Tags = mergeTags(generated.Tags, existing.Tags, previous)
+	merged.Description = mergeDescription(existing.Description, generated.Description)
+	merged.Private = existing.Private
+	merged.ToRead = existing.ToRead
+
+	return merged
+}
+
+// mergeTags returns the generated tags followed by any existing tags that
+// aren't among them, leaving out previously generated tags that are no longer
+// generated.
+func mergeTags(generated, existing, previous []string) []string {
+	seen := make(map[string]bool, len(generated)+len(previous))
+	for _, tag := range previous {
+		seen[tag] = true
+	}
+	for _, tag := range generated {
+		seen[tag] = false
+	}
+
+	tags := make([]string, 0, len(generated)+len(existing))
+	for _, tag := range append(append([]string(nil), generated...), existing...) {
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// mergeDescription replaces the managed block in existing with generated,
+// appending a new block if there isn't one.
+func mergeDescription(existing, generated string) string {
+	block := ManagedBlock(generated)
+
+	start := strings.Index(existing, managedStart)
+	end := strings.Index(existing, managedEnd)
+	if start >= 0 && end > start {
+		return existing[:start] + block + existing[end+len(managedEnd):]
+	}
+
+	notes := strings.TrimSpace(existing)
+	if notes == "" || notes == strings.TrimSpace(generated) {
+		return block
+	}
+
+	return notes + "\n\n" + block
+}
@@ -0,0 +1,293 @@
+package export
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/monooso/gitboard/github"
+	"github.com/monooso/gitboard/pinboard"
+	"github.com/monooso/gitboard/state"
+)
+
+// Test MergeBookmark keeps user tags and notes while refreshing generated content.
+func TestMergeBookmark(t *testing.T) {
+	tests := []struct {
+		name                string
+		existingDescription string
+		expectedDescription string
+	}{
+		{
+			name:                "replaces managed block",
+			existingDescription: "My notes\n\n[gitboard]\nOld text\n[/gitboard]\nMore notes",
+			expectedDescription: "My notes\n\n[gitboard]\nNew text\n[/gitboard]\nMore notes",
+		},
+		{
+			name:                "keeps unmanaged notes",
+			existingDescription: "Written by hand",
+			expectedDescription: "Written by hand\n\n[gitboard]\nNew text\n[/gitboard]",
+		},
+		{
+			name:                "empty description",
+			existingDescription: "",
+			expectedDescription: "[gitboard]\nNew text\n[/gitboard]",
+		},
+		{
+			name:                "unmanaged copy of generated text",
+			existingDescription: "New text",
+			expectedDescription: "[gitboard]\nNew text\n[/gitboard]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := pinboard.Bookmark{
+				URL:         "https://github.com/a/b",
+				Title:       "Renamed by hand",
+				Description: tt.existingDescription,
+				Tags:        []string{"github-repo", "old-topic", "favourite"},
+				Private:     false,
+				ToRead:      true,
+			}
+			generated := pinboard.Bookmark{
+				URL:         "https://github.com/a/b",
+				Title:       "a/b",
+				Description: "New text",
+				Tags:        []string{"github-repo", "go"},
+				Private:     true,
+			}
+
+			merged := MergeBookmark(existing, generated, nil)
+
+			if merged.Description != tt.expectedDescription {
+				t.Errorf("expected description %q, got %q", tt.expectedDescription, merged.Description)
+			}
+			if merged.Title != "a/b" {
+				t.Errorf("expected generated title, got %q", merged.Title)
+			}
+			if merged.Private || !merged.ToRead {
+				t.Errorf("expected existing privacy and read status, got %+v", merged)
+			}
+
+			expectedTags := []string{"github-repo", "go", "old-topic", "favourite"}
+			if len(merged.Tags) != len(expectedTags) {
+				t.Fatalf("expected tags %v, got %v", expectedTags, merged.Tags)
+			}
+			for i, tag := range expectedTags {
+				if merged.Tags[i] != tag {
+					t.Errorf("expected tag %q, got %q", tag, merged.Tags[i])
+				}
+			}
+		})
+	}
+}
+
+// Test mergeTags drops previously generated tags that are no longer
+// generated, but keeps the user's own.
+func TestMergeTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []string
+		expected string
+	}{
+		{
+			name:     "nothing recorded",
+			previous: nil,
+			expected: "github-repo new-topic old-topic mine",
+		},
+		{
+			name:     "removed topic",
+			previous: []string{"github-repo", "old-topic"},
+			expected: "github-repo new-topic mine",
+		},
+		{
+			name:     "topic still generated",
+			previous: []string{"github-repo", "new-topic", "old-topic"},
+			expected: "github-repo new-topic mine",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated := []string{"github-repo", "new-topic"}
+			existing := []string{"github-repo", "old-topic", "mine"}
+
+			got := strings.Join(mergeTags(generated, existing, tt.previous), " ")
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// Test ParseMergeStrategy accepts known strategies and rejects others.
+func TestParseMergeStrategy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected MergeStrategy
+		wantErr  bool
+	}{
+		{input: "", expected: MergeOverwrite},
+		{input: "overwrite", expected: MergeOverwrite},
+		{input: "merge", expected: MergeMerge},
+		{input: "never", expected: MergeNever},
+		{input: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			strategy, err := ParseMergeStrategy(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strategy != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, strategy)
+			}
+		})
+	}
+}
+
+// Test Run merges updates into hand-curated bookmarks.
+func TestRunUpdateMerge(t *testing.T) {
+	curated := pinboard.Bookmark{
+		URL:         "https://github.com/a/b",
+		Title:       "a/b",
+		Description: "Great for parsing\n\n[gitboard]\nOld\n[/gitboard]",
+		Tags:        []string{"github-repo", "favourite"},
+		Private:     true,
+	}
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Description: "New", Topics: []string{"go"}},
+		{FullName: "a/new", HTMLURL: "https://github.com/a/new", Description: "Brand new"},
+	}}
+	pbClient := &mockPinboardClient{
+		storedBookmarks: map[string]pinboard.Bookmark{curated.URL: curated},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Update = true
+	exporter.Merge = MergeMerge
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Updated != 1 || result.Added != 1 {
+		t.Errorf("expected Updated=1 Added=1, got %+v", result)
+	}
+	if len(pbClient.addedBookmarks) != 2 {
+		t.Fatalf("expected 2 writes, got %d", len(pbClient.addedBookmarks))
+	}
+
+	updated := pbClient.addedBookmarks[0]
+	if updated.Description != "Great for parsing\n\n[gitboard]\nNew\n[/gitboard]" {
+		t.Errorf("expected notes to be kept, got %q", updated.Description)
+	}
+	if len(updated.Tags) != 3 || updated.Tags[2] != "favourite" {
+		t.Errorf("expected user tag to be kept, got %v", updated.Tags)
+	}
+
+	added := pbClient.addedBookmarks[1]
+	if added.Description != "[gitboard]\nBrand new\n[/gitboard]" {
+		t.Errorf("expected new bookmark to use a managed block, got %q", added.Description)
+	}
+}
+
+// Test Run skips merged bookmarks that are already up to date.
+func TestRunUpdateMergeUnchanged(t *testing.T) {
+	curated := pinboard.Bookmark{
+		URL:         "https://github.com/a/b",
+		Title:       "a/b",
+		Description: "Notes\n\n[gitboard]\nSame\n[/gitboard]",
+		Tags:        []string{"github-repo", "favourite"},
+	}
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Description: "Same"},
+	}}
+	pbClient := &mockPinboardClient{
+		storedBookmarks: map[string]pinboard.Bookmark{curated.URL: curated},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Update = true
+	exporter.Merge = MergeMerge
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Skipped != 1 || len(pbClient.addedBookmarks) != 0 {
+		t.Errorf("expected bookmark to be skipped, got %+v with %d writes", result, len(pbClient.addedBookmarks))
+	}
+}
+
+// Test Run never rewrites existing bookmarks with MergeNever.
+func TestRunUpdateMergeNever(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Description: "New"},
+	}}
+	pbClient := &mockPinboardClient{
+		storedBookmarks: map[string]pinboard.Bookmark{
+			"https://github.com/a/b": {URL: "https://github.com/a/b", Title: "Mine", Description: "Old"},
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Update = true
+	exporter.Merge = MergeNever
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Skipped != 1 || result.Updated != 0 || len(pbClient.addedBookmarks) != 0 {
+		t.Errorf("expected bookmark to be left alone, got %+v", result)
+	}
+}
+
+// Test Run drops the tag for a topic removed from the repository when
+// merging, using the tags recorded in State, and records the new ones.
+func TestRunUpdateMergeRemovedTopic(t *testing.T) {
+	curated := pinboard.Bookmark{
+		URL:         "https://github.com/a/b",
+		Title:       "a/b",
+		Description: "[gitboard]\nSame\n[/gitboard]",
+		Tags:        []string{"github-repo", "old-topic", "mine"},
+	}
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Description: "Same", Topics: []string{"new-topic"}},
+	}}
+	pbClient := &mockPinboardClient{
+		storedBookmarks: map[string]pinboard.Bookmark{curated.URL: curated},
+	}
+	store := newTestStore(t)
+	store.Put(curated.URL, state.Entry{Name: "a/b", Tags: []string{"github-repo", "old-topic"}})
+
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Update = true
+	exporter.Merge = MergeMerge
+	exporter.State = store
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Updated != 1 || len(pbClient.addedBookmarks) != 1 {
+		t.Fatalf("expected 1 update, got %+v", result)
+	}
+	if got := strings.Join(pbClient.addedBookmarks[0].Tags, " "); got != "github-repo new-topic mine" {
+		t.Errorf("expected the removed topic dropped, got %q", got)
+	}
+
+	entry, _ := store.Get(curated.URL)
+	if got := strings.Join(entry.Tags, " "); got != "github-repo new-topic" {
+		t.Errorf("expected generated tags recorded, got %q", got)
+	}
+}
@@ -0,0 +1,142 @@
+package export
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/monooso/gitboard/pinboard"
+)
+
+// DefaultStarTag is the Pinboard tag that marks bookmarks to be starred.
+const DefaultStarTag = "to-star"
+
+// RepoStarrer defines the interface for starring GitHub repositories.
+type RepoStarrer interface {
+	StarRepo(ctx context.Context, owner, repo string) error
+}
+
+// BookmarkLister defines the interface for listing Pinboard bookmarks.
+type BookmarkLister interface {
+	GetBookmarksByTag(ctx context.Context, tag string) ([]pinboard.Bookmark, error)
+}
+
+// StarResult summarises a completed star operation.
+type StarResult struct {
+	Total   int
+	Starred int
+	Skipped int
+
+	// Repos lists the full names of the repositories that were starred, or
+	// would have been in a dry run.
+	Repos []string
+}
+
+// Starrer stars the GitHub repositories bookmarked in Pinboard with a
+// given tag. It is the reverse of Exporter.
+type Starrer struct {
+	pb         BookmarkLister
+	gh         RepoStarrer
+	Tag        string
+	DryRun     bool
+	OnProgress func(Progress)
+}
+
+// NewStarrer creates a new Starrer with the provided clients.
+func NewStarrer(pb BookmarkLister, gh RepoStarrer) *Starrer {
+	return &Starrer{
+		pb:  pb,
+		gh:  gh,
+		Tag: DefaultStarTag,
+	}
+}
+
+// Run fetches the bookmarks tagged with Tag and stars every GitHub
+// repository among them. Bookmarks that don't point to a repository, or
+// that repeat one already seen, are skipped.
+func (s *Starrer) Run(ctx context.Context) (StarResult, error) {
+	bookmarks, err := s.pb.GetBookmarksByTag(ctx, s.Tag)
+	if err != nil {
+		return StarResult{}, err
+	}
+
+	result := StarResult{Total: len(bookmarks)}
+	seen := make(map[string]bool, len(bookmarks))
+
+	for i, b := range bookmarks {
+		owner, name, ok := ParseRepoURL(b.URL)
+		fullName := owner + "/" + name
+		key := strings.ToLower(fullName)
+
+		action := ActionStar
+		if !ok || seen[key] {
+			action = ActionSkip
+			fullName = ""
+		} else {
+			seen[key] = true
+		}
+
+		if s.OnProgress != nil {
+			s.OnProgress(Progress{
+				Current:  i + 1,
+				Total:    len(bookmarks),
+				RepoName: fullName,
+				URL:      b.URL,
+				Action:   action,
+			})
+		}
+
+		if action == ActionSkip {
+			result.Skipped++
+			continue
+		}
+
+		if !s.DryRun {
+			if err := s.gh.StarRepo(ctx, owner, name); err != nil {
+				return result, err
+			}
+		}
+		result.Starred++
+		result.Repos = append(result.Repos, fullName)
+	}
+
+	return result, nil
+}
+
+// reservedOwners are top-level github.com paths that look like owners but
+// aren't.
+var reservedOwners = map[string]bool{
+	"about": true, "apps": true, "collections": true, "enterprise": true,
+	"explore": true, "features": true, "login": true, "marketplace": true,
+	"notifications": true, "orgs": true, "pricing": true, "search": true,
+	"settings": true, "sponsors": true, "topics": true, "trending": true,
+	"users": true,
+}
+
+// ParseRepoURL extracts the owner and repository name from a github.com
+// repository URL. Deeper paths such as /owner/repo/issues still identify the
+// repository. It returns false for anything that isn't a repository URL.
+func ParseRepoURL(raw string) (owner, name string, ok bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", "", false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host != "github.com" {
+		return "", "", false
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	owner = parts[0]
+	name = strings.TrimSuffix(parts[1], ".git")
+	if reservedOwners[strings.ToLower(owner)] || name == "" {
+		return "", "", false
+	}
+
+	return owner, name, true
+}
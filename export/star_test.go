@@ -0,0 +1,163 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/monooso/gitboard/pinboard"
+)
+
+type mockStarrer struct {
+	starred []string
+	err     error
+}
+
+func (m *mockStarrer) StarRepo(ctx context.Context, owner, repo string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.starred = append(m.starred, owner+"/"+repo)
+	return nil
+}
+
+// Test ParseRepoURL recognises GitHub repository URLs.
+func TestParseRepoURL(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedOwner string
+		expectedName  string
+		expectedOK    bool
+	}{
+		{input: "https://github.com/golang/go", expectedOwner: "golang", expectedName: "go", expectedOK: true},
+		{input: "http://www.github.com/Golang/Go/", expectedOwner: "Golang", expectedName: "Go", expectedOK: true},
+		{input: "https://github.com/golang/go.git", expectedOwner: "golang", expectedName: "go", expectedOK: true},
+		{input: "https://github.com/golang/go/issues/1", expectedOwner: "golang", expectedName: "go", expectedOK: true},
+		{input: "https://github.com/golang/go?tab=readme#top", expectedOwner: "golang", expectedName: "go", expectedOK: true},
+		{input: "https://github.com/golang", expectedOK: false},
+		{input: "https://github.com/topics/go", expectedOK: false},
+		{input: "https://gist.github.com/user/abc123", expectedOK: false},
+		{input: "https://gitlab.com/owner/repo", expectedOK: false},
+		{input: "not a url", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			owner, name, ok := ParseRepoURL(tt.input)
+			if ok != tt.expectedOK {
+				t.Fatalf("expected ok=%v, got %v", tt.expectedOK, ok)
+			}
+			if owner != tt.expectedOwner || name != tt.expectedName {
+				t.Errorf("expected %s/%s, got %s/%s", tt.expectedOwner, tt.expectedName, owner, name)
+			}
+		})
+	}
+}
+
+// Test Starrer.Run stars each repository bookmarked with the tag.
+func TestStarrerRun(t *testing.T) {
+	pbClient := &mockPinboardClient{
+		storedBookmarks: map[string]pinboard.Bookmark{
+			"https://github.com/golang/go": {URL: "https://github.com/golang/go"},
+		},
+	}
+	ghClient := &mockStarrer{}
+	starrer := NewStarrer(pbClient, ghClient)
+
+	result, err := starrer.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Starred != 1 || result.Total != 1 {
+		t.Errorf("expected Starred=1 Total=1, got %+v", result)
+	}
+	if len(ghClient.starred) != 1 || ghClient.starred[0] != "golang/go" {
+		t.Errorf("expected golang/go to be starred, got %v", ghClient.starred)
+	}
+	if len(result.Repos) != 1 || result.Repos[0] != "golang/go" {
+		t.Errorf("expected golang/go in summary, got %v", result.Repos)
+	}
+}
+
+// Test Starrer.Run skips non-repository and duplicate bookmarks.
+func TestStarrerRunSkips(t *testing.T) {
+	pbClient := &listingPinboardClient{bookmarks: []pinboard.Bookmark{
+		{URL: "https://github.com/golang/go"},
+		{URL: "https://example.com/article"},
+		{URL: "https://github.com/Golang/Go/"},
+	}}
+	ghClient := &mockStarrer{}
+	starrer := NewStarrer(pbClient, ghClient)
+	starrer.Tag = "star-me"
+
+	var actions []Action
+	starrer.OnProgress = func(p Progress) {
+		actions = append(actions, p.Action)
+	}
+
+	result, err := starrer.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pbClient.tag != "star-me" {
+		t.Errorf("expected bookmarks tagged star-me, got %q", pbClient.tag)
+	}
+	if result.Total != 3 || result.Starred != 1 || result.Skipped != 2 {
+		t.Errorf("expected Total=3 Starred=1 Skipped=2, got %+v", result)
+	}
+	expectedActions := []Action{ActionStar, ActionSkip, ActionSkip}
+	for i, action := range expectedActions {
+		if actions[i] != action {
+			t.Errorf("expected action %q at %d, got %q", action, i, actions[i])
+		}
+	}
+}
+
+// Test Starrer.Run does not star anything in a dry run.
+func TestStarrerRunDryRun(t *testing.T) {
+	pbClient := &listingPinboardClient{bookmarks: []pinboard.Bookmark{
+		{URL: "https://github.com/golang/go"},
+	}}
+	ghClient := &mockStarrer{}
+	starrer := NewStarrer(pbClient, ghClient)
+	starrer.DryRun = true
+
+	result, err := starrer.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Starred != 1 {
+		t.Errorf("expected Starred=1, got %d", result.Starred)
+	}
+	if len(ghClient.starred) != 0 {
+		t.Errorf("expected nothing starred in dry run, got %v", ghClient.starred)
+	}
+}
+
+// Test Starrer.Run returns GitHub errors.
+func TestStarrerRunError(t *testing.T) {
+	pbClient := &listingPinboardClient{bookmarks: []pinboard.Bookmark{
+		{URL: "https://github.com/golang/go"},
+	}}
+	expectedErr := errors.New("star failed")
+	starrer := NewStarrer(pbClient, &mockStarrer{err: expectedErr})
+
+	_, err := starrer.Run(context.Background())
+	if !errors.Is(err, expectedErr) {
+		t.Errorf("expected %v, got %v", expectedErr, err)
+	}
+}
+
+// listingPinboardClient returns a fixed, ordered list of bookmarks.
+type listingPinboardClient struct {
+	bookmarks []pinboard.Bookmark
+	tag       string
+}
+
+func (m *listingPinboardClient) GetBookmarksByTag(ctx context.Context, tag string) ([]pinboard.Bookmark, error) {
+	m.tag = tag
+	return m.bookmarks, nil
+}
@@ -0,0 +1,256 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/monooso/gitboard/github"
+)
+
+// MaxTagLength is the longest tag, in characters, that Pinboard accepts.
+const MaxTagLength = 255
+
+// DefaultListPrefix is prepended to tags made from star lists, unless the tag
+// rules say otherwise.
+const DefaultListPrefix = "list:"
+
+// TagChange records a tag that had to be altered or dropped to make it safe
+// for Pinboard.
+type TagChange struct {
+	Original string
+	// Result is the sanitised tag, or empty if the tag was dropped.
+	Result string
+	Reason string
+}
+
+// String describes the change for display.
+func (c TagChange) String() string {
+	if c.Result == "" {
+		return fmt.Sprintf("tag %q dropped (%s)", c.Original, c.Reason)
+	}
+	return fmt.Sprintf("tag %q changed to %q (%s)", c.Original, c.Result, c.Reason)
+}
+
+// TagRules customises how repository topics become bookmark tags. The zero
+// value passes every topic through unchanged and uses MarkerTag.
+type TagRules struct {
+	// MarkerTag replaces the default marker tag, which identifies the
+	// bookmarks gitboard manages.
+	MarkerTag string `json:"marker_tag,omitempty"`
+
+	// Rename maps a topic to the tag used in its place, e.g. golang to go.
+	// Renaming a topic to an empty string drops it.
+	Rename map[string]string `json:"rename,omitempty"`
+
+	// Drop lists topics that never become tags. They are matched before
+	// renaming.
+	Drop []string `json:"drop,omitempty"`
+
+	// Prefix is prepended to every topic tag, e.g. "gh:".
+	Prefix string `json:"prefix,omitempty"`
+
+	// Language adds the repository's primary language as a topic, so it
+	// becomes a tag like any other topic.
+	Language bool `json:"language,omitempty"`
+
+	// ListPrefix is prepended to the tags made from star lists. It defaults
+	// to DefaultListPrefix.
+	ListPrefix string `json:"list_prefix,omitempty"`
+
+	// MaxTags caps the number of tags on a bookmark, including the marker
+	// tag, which is always kept. Zero means no limit.
+	MaxTags int `json:"max_tags,omitempty"`
+}
+
+// LoadTagRules reads tag rules from a JSON file.
+func LoadTagRules(path string) (TagRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return TagRules{}, fmt.Errorf("failed to read tag rules: %w", err)
+	}
+
+	var rules TagRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return TagRules{}, fmt.Errorf("failed to parse tag rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Marker returns the marker tag to use, falling back to MarkerTag.
+func (r TagRules) Marker() string {
+	if r.MarkerTag == "" {
+		return MarkerTag
+	}
+	return r.MarkerTag
+}
+
+// ListTagPrefix returns the star list tag prefix to use, falling back
+// to DefaultListPrefix.
+func (r TagRules) ListTagPrefix() string {
+	if r.ListPrefix == "" {
+		return DefaultListPrefix
+	}
+	return r.ListPrefix
+}
+
+// ListTags turns star list names into tags, e.g. "Dev Tools" becomes
+// list:dev-tools. Drop, Rename and Prefix apply to topics only.
+func ListTags(lists []string, rules TagRules) []string {
+	tags := make([]string, 0, len(lists))
+	seen := make(map[string]bool, len(lists))
+	for _, name := range NormaliseTags(lists) {
+		tag := rules.ListTagPrefix() + name
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// RepoTopics returns the repository's topics, with its primary language
+// first if the rules ask for it.
+func RepoTopics(repo github.StarredRepo, rules TagRules) []string {
+	if !rules.Language || repo.Language == "" {
+		return repo.Topics
+	}
+	return append([]string{repo.Language}, repo.Topics...)
+}
+
+// NormaliseTags normalises topic tags by converting to lowercase and replacing spaces with hyphens.
+func NormaliseTags(topics []string) []string {
+	if len(topics) == 0 {
+		return []string{}
+	}
+
+	normalised := make([]string, len(topics))
+	for i, topic := range topics {
+		normalised[i] = strings.ToLower(strings.ReplaceAll(topic, " ", "-"))
+	}
+	return normalised
+}
+
+// ApplyTagRules drops, renames, and prefixes normalised topics according to
+// rules, removing any duplicates this creates and capping the result so the
+// bookmark stays within MaxTags once the marker tag is added.
+func ApplyTagRules(topics []string, rules TagRules) []string {
+	drop := make(map[string]bool, len(rules.Drop))
+	for _, topic := range rules.Drop {
+		drop[topic] = true
+	}
+
+	limit := len(topics)
+	if rules.MaxTags > 0 {
+		limit = min(limit, rules.MaxTags-1)
+	}
+
+	tags := []string{}
+	seen := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		if len(tags) >= limit {
+			break
+		}
+		if drop[topic] {
+			continue
+		}
+
+		if renamed, ok := rules.Rename[topic]; ok {
+			topic = renamed
+		}
+		if topic == "" {
+			continue
+		}
+
+		tag := rules.Prefix + topic
+		if seen[tag] || tag == rules.Marker() {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// SanitiseTags makes tags safe for Pinboard. Whitespace of any kind becomes a
+// hyphen, commas are removed, leading dots (which would make a tag private)
+// are stripped, and over-long tags are truncated to MaxTagLength. Tags that
+// end up empty, or that duplicate an earlier tag ignoring case, are dropped.
+// The order of the remaining tags is preserved. Any tag that changed is
+// reported in the returned list.
+func SanitiseTags(tags []string) ([]string, []TagChange) {
+	sanitised := make([]string, 0, len(tags))
+	var changes []TagChange
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		result, reasons := sanitiseTag(tag)
+
+		switch key := strings.ToLower(result); {
+		case result == "":
+			changes = append(changes, TagChange{Original: tag, Reason: "empty"})
+			continue
+		case seen[key]:
+			changes = append(changes, TagChange{Original: tag, Reason: "duplicate"})
+			continue
+		default:
+			seen[key] = true
+		}
+
+		if len(reasons) > 0 {
+			changes = append(changes, TagChange{
+				Original: tag,
+				Result:   result,
+				Reason:   strings.Join(reasons, ", "),
+			})
+		}
+		sanitised = append(sanitised, result)
+	}
+
+	return sanitised, changes
+}
+
+// sanitiseTag applies the SanitiseTags rules to a single tag, returning the
+// result and the reasons it changed.
+func sanitiseTag(tag string) (string, []string) {
+	var reasons []string
+	var b strings.Builder
+	var replacedSpace, removedComma bool
+
+	for _, r := range tag {
+		switch {
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+			replacedSpace = true
+		case r == ',':
+			removedComma = true
+		default:
+			b.WriteRune(r)
+		}
+	}
+	result := b.String()
+
+	if replacedSpace {
+		reasons = append(reasons, "whitespace replaced")
+	}
+	if removedComma {
+		reasons = append(reasons, "commas removed")
+	}
+
+	if trimmed := strings.TrimLeft(result, "."); trimmed != result {
+		result = trimmed
+		reasons = append(reasons, "leading dot removed")
+	}
+
+	if utf8.RuneCountInString(result) > MaxTagLength {
+		result = string([]rune(result)[:MaxTagLength])
+		reasons = append(reasons, fmt.Sprintf("truncated to %d characters", MaxTagLength))
+	}
+
+	return result, reasons
+}
@@ -0,0 +1,413 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/monooso/gitboard/github"
+)
+
+// Test ApplyTagRules renames, drops, prefixes, and caps topics.
+func TestApplyTagRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		topics   []string
+		rules    TagRules
+		expected []string
+	}{
+		{
+			name:     "zero rules pass topics through",
+			topics:   []string{"go", "cli"},
+			rules:    TagRules{},
+			expected: []string{"go", "cli"},
+		},
+		{
+			name:     "rename",
+			topics:   []string{"golang", "cli"},
+			rules:    TagRules{Rename: map[string]string{"golang": "go"}},
+			expected: []string{"go", "cli"},
+		},
+		{
+			name:     "rename to empty drops",
+			topics:   []string{"hacktoberfest", "cli"},
+			rules:    TagRules{Rename: map[string]string{"hacktoberfest": ""}},
+			expected: []string{"cli"},
+		},
+		{
+			name:     "drop",
+			topics:   []string{"awesome", "go", "awesome-list"},
+			rules:    TagRules{Drop: []string{"awesome", "awesome-list"}},
+			expected: []string{"go"},
+		},
+		{
+			name:     "prefix",
+			topics:   []string{"go", "cli"},
+			rules:    TagRules{Prefix: "gh:"},
+			expected: []string{"gh:go", "gh:cli"},
+		},
+		{
+			name:     "rename collisions are deduplicated",
+			topics:   []string{"golang", "go"},
+			rules:    TagRules{Rename: map[string]string{"golang": "go"}},
+			expected: []string{"go"},
+		},
+		{
+			name:     "max tags leaves room for the marker",
+			topics:   []string{"a", "b", "c", "d"},
+			rules:    TagRules{MaxTags: 3},
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "max tags counts kept tags only",
+			topics:   []string{"noise", "a", "b", "c"},
+			rules:    TagRules{MaxTags: 3, Drop: []string{"noise"}},
+			expected: []string{"a", "b"},
+		},
+		{
+			name:     "topic matching the marker is not repeated",
+			topics:   []string{"github-repo", "go"},
+			rules:    TagRules{},
+			expected: []string{"go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ApplyTagRules(tt.topics, tt.rules)
+			if len(result) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, result)
+			}
+			for i, tag := range result {
+				if tag != tt.expected[i] {
+					t.Errorf("expected tag %q, got %q", tt.expected[i], tag)
+				}
+			}
+		})
+	}
+}
+
+// Test LoadTagRules reads rules from a JSON file.
+func TestLoadTagRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	data := `{
+		"marker_tag": "starred",
+		"rename": {"golang": "go"},
+		"drop": ["awesome"],
+		"prefix": "gh:",
+		"max_tags": 5
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+
+	rules, err := LoadTagRules(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if rules.Marker() != "starred" {
+		t.Errorf("expected marker %q, got %q", "starred", rules.Marker())
+	}
+	if rules.Rename["golang"] != "go" || len(rules.Drop) != 1 || rules.Prefix != "gh:" || rules.MaxTags != 5 {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+}
+
+// Test LoadTagRules reports malformed files.
+func TestLoadTagRulesInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+
+	if _, err := LoadTagRules(path); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+// Test Run applies tag rules and uses the configured marker tag.
+func TestRunTagRules(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Topics: []string{"Golang", "awesome"}},
+	}}
+	pbClient := &mockPinboardClient{}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.TagRules = TagRules{
+		MarkerTag: "starred",
+		Rename:    map[string]string{"golang": "go"},
+		Drop:      []string{"awesome"},
+		Prefix:    "gh:",
+	}
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pbClient.lastTag != "starred" {
+		t.Errorf("expected existing bookmarks to be fetched by %q, got %q", "starred", pbClient.lastTag)
+	}
+	if len(pbClient.addedBookmarks) != 1 {
+		t.Fatalf("expected 1 bookmark, got %d", len(pbClient.addedBookmarks))
+	}
+	tags := pbClient.addedBookmarks[0].Tags
+	if len(tags) != 2 || tags[0] != "starred" || tags[1] != "gh:go" {
+		t.Errorf("expected tags [starred gh:go], got %v", tags)
+	}
+}
+
+// listingGitHubClient is a mockGitHubClient that also returns star lists.
+type listingGitHubClient struct {
+	mockGitHubClient
+	lists []github.StarList
+}
+
+func (m *listingGitHubClient) GetStarLists(ctx context.Context) ([]github.StarList, error) {
+	return m.lists, nil
+}
+
+// Test ListTags normalises and prefixes star list names.
+func TestListTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		lists    []string
+		rules    TagRules
+		expected []string
+	}{
+		{name: "no lists", lists: nil, expected: []string{}},
+		{name: "default prefix", lists: []string{"Dev Tools", "go"}, expected: []string{"list:dev-tools", "list:go"}},
+		{name: "custom prefix", lists: []string{"tools"}, rules: TagRules{ListPrefix: "sl:"}, expected: []string{"sl:tools"}},
+		{name: "duplicates", lists: []string{"Tools", "tools"}, expected: []string{"list:tools"}},
+		{
+			name:     "topic rules ignored",
+			lists:    []string{"golang"},
+			rules:    TagRules{Rename: map[string]string{"golang": "go"}, Prefix: "gh:"},
+			expected: []string{"list:golang"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ListTags(tt.lists, tt.rules)
+			if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// Test RepoToBookmarkWithRules puts list tags before topics, and topics make
+// way for them under MaxTags.
+func TestRepoToBookmarkWithLists(t *testing.T) {
+	repo := github.StarredRepo{
+		FullName: "a/b",
+		HTMLURL:  "https://github.com/a/b",
+		Topics:   []string{"cli", "go"},
+		Lists:    []string{"Tools"},
+	}
+
+	bookmark, _ := RepoToBookmarkWithRules(repo, TagRules{})
+	if got := strings.Join(bookmark.Tags, " "); got != "github-repo list:tools cli go" {
+		t.Errorf("unexpected tags %q", got)
+	}
+
+	bookmark, _ = RepoToBookmarkWithRules(repo, TagRules{MaxTags: 3})
+	if got := strings.Join(bookmark.Tags, " "); got != "github-repo list:tools cli" {
+		t.Errorf("unexpected capped tags %q", got)
+	}
+}
+
+// Test RepoTopics adds the primary language only when the rules ask for it.
+func TestRepoTopics(t *testing.T) {
+	repo := github.StarredRepo{Topics: []string{"cli"}, Language: "Go"}
+
+	if got := strings.Join(RepoTopics(repo, TagRules{}), " "); got != "cli" {
+		t.Errorf("expected topics only, got %q", got)
+	}
+	if got := strings.Join(RepoTopics(repo, TagRules{Language: true}), " "); got != "Go cli" {
+		t.Errorf("expected language first, got %q", got)
+	}
+
+	repo.Language = ""
+	if got := strings.Join(RepoTopics(repo, TagRules{Language: true}), " "); got != "cli" {
+		t.Errorf("expected no language tag, got %q", got)
+	}
+
+	// Language tags go through the topic rules.
+	repo.Language = "Golang"
+	bookmark, _ := RepoToBookmarkWithRules(repo, TagRules{Language: true, Rename: map[string]string{"golang": "go"}})
+	if got := strings.Join(bookmark.Tags, " "); got != "github-repo go cli" {
+		t.Errorf("unexpected tags %q", got)
+	}
+}
+
+// Test Run fetches star lists, tags bookmarks with them, and filters by them.
+func TestRunStarLists(t *testing.T) {
+	ghClient := &listingGitHubClient{
+		mockGitHubClient: mockGitHubClient{repos: []github.StarredRepo{
+			{FullName: "a/tool", HTMLURL: "https://github.com/a/tool"},
+			{FullName: "a/toy", HTMLURL: "https://github.com/a/toy"},
+			{FullName: "a/other", HTMLURL: "https://github.com/a/other"},
+		}},
+		lists: []github.StarList{
+			{Name: "Dev Tools", Repos: []string{"A/Tool"}},
+			{Name: "Toys", Repos: []string{"a/toy"}},
+		},
+	}
+	pbClient := &mockPinboardClient{}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.StarLists = true
+	exporter.Filter = Filter{ExcludeLists: []string{"toys"}}
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Added != 2 || result.Filtered != 1 {
+		t.Errorf("expected 2 added and 1 filtered, got %+v", result)
+	}
+	if len(pbClient.addedBookmarks) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %d", len(pbClient.addedBookmarks))
+	}
+	if got := strings.Join(pbClient.addedBookmarks[0].Tags, " "); got != "github-repo list:dev-tools" {
+		t.Errorf("unexpected tags %q", got)
+	}
+	if got := strings.Join(pbClient.addedBookmarks[1].Tags, " "); got != "github-repo" {
+		t.Errorf("unexpected tags %q", got)
+	}
+}
+
+// Test Run fails if star lists are wanted but the client can't fetch them.
+func TestRunStarListsUnsupported(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b"},
+	}}
+	exporter := NewExporter(ghClient, &mockPinboardClient{})
+	exporter.StarLists = true
+
+	if _, err := exporter.Run(context.Background()); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+// Test SanitiseTags makes tags safe for Pinboard and reports lossy changes.
+func TestSanitiseTags(t *testing.T) {
+	long := strings.Repeat("a", MaxTagLength+10)
+
+	tests := []struct {
+		name            string
+		input           []string
+		expected        []string
+		expectedChanges []TagChange
+	}{
+		{
+			name:     "already safe",
+			input:    []string{"go", "cli"},
+			expected: []string{"go", "cli"},
+		},
+		{
+			name:     "unicode whitespace",
+			input:    []string{"machine\tlearning", "data science"},
+			expected: []string{"machine-learning", "data-science"},
+			expectedChanges: []TagChange{
+				{Original: "machine\tlearning", Result: "machine-learning", Reason: "whitespace replaced"},
+				{Original: "data science", Result: "data-science", Reason: "whitespace replaced"},
+			},
+		},
+		{
+			name:     "commas",
+			input:    []string{"c,c++"},
+			expected: []string{"cc++"},
+			expectedChanges: []TagChange{
+				{Original: "c,c++", Result: "cc++", Reason: "commas removed"},
+			},
+		},
+		{
+			name:     "leading dots",
+			input:    []string{"..dotfiles"},
+			expected: []string{"dotfiles"},
+			expectedChanges: []TagChange{
+				{Original: "..dotfiles", Result: "dotfiles", Reason: "leading dot removed"},
+			},
+		},
+		{
+			name:     "over length",
+			input:    []string{long},
+			expected: []string{long[:MaxTagLength]},
+			expectedChanges: []TagChange{
+				{Original: long, Result: long[:MaxTagLength], Reason: "truncated to 255 characters"},
+			},
+		},
+		{
+			name:     "case-insensitive duplicates keep the first",
+			input:    []string{"Go", "cli", "go"},
+			expected: []string{"Go", "cli"},
+			expectedChanges: []TagChange{
+				{Original: "go", Reason: "duplicate"},
+			},
+		},
+		{
+			name:     "empty after sanitising",
+			input:    []string{"go", ".", ","},
+			expected: []string{"go"},
+			expectedChanges: []TagChange{
+				{Original: ".", Reason: "empty"},
+				{Original: ",", Reason: "empty"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, changes := SanitiseTags(tt.input)
+
+			if len(result) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, result)
+			}
+			for i, tag := range result {
+				if tag != tt.expected[i] {
+					t.Errorf("expected tag %q, got %q", tt.expected[i], tag)
+				}
+			}
+
+			if len(changes) != len(tt.expectedChanges) {
+				t.Fatalf("expected changes %v, got %v", tt.expectedChanges, changes)
+			}
+			for i, change := range changes {
+				if change != tt.expectedChanges[i] {
+					t.Errorf("expected change %+v, got %+v", tt.expectedChanges[i], change)
+				}
+			}
+		})
+	}
+}
+
+// Test Run reports sanitised tags through the progress callback.
+func TestRunReportsTagChanges(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Topics: []string{"c,c++", "go"}},
+	}}
+	pbClient := &mockPinboardClient{}
+	exporter := NewExporter(ghClient, pbClient)
+
+	var changes []TagChange
+	exporter.OnProgress = func(p Progress) {
+		changes = append(changes, p.TagChanges...)
+	}
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(changes) != 1 || changes[0].Original != "c,c++" || changes[0].Result != "cc++" {
+		t.Errorf("expected comma removal to be reported, got %v", changes)
+	}
+
+	tags := pbClient.addedBookmarks[0].Tags
+	if len(tags) != 3 || tags[1] != "cc++" {
+		t.Errorf("expected sanitised tags, got %v", tags)
+	}
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/monooso/gitboard/github"
+)
+
+// templateFuncs are the helper functions available to title and description
+// templates. Functions that take a value as well as options expect the value
+// last, so they work at the end of a pipeline.
+var templateFuncs = template.FuncMap{
+	"truncate": truncate,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"default": func(fallback, s string) string {
+		if s == "" {
+			return fallback
+		}
+		return s
+	},
+	"join": func(sep string, items []string) string {
+		return strings.Join(items, sep)
+	},
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+}
+
+// sampleRepo is used to check templates when they are parsed, so mistakes
+// such as unknown fields are caught before an export starts.
+var sampleRepo = github.StarredRepo{
+	FullName:    "owner/repo",
+	HTMLURL:     "https://github.com/owner/repo",
+	Description: "A sample repository",
+	Topics:      []string{"sample"},
+	StarredAt:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	Lists:       []string{"Sample list"},
+	Language:    "Go",
+	Homepage:    "https://example.com",
+	License:     "MIT",
+}
+
+// ParseTemplate parses a bookmark title or description template. Templates
+// are executed with a github.StarredRepo, so they can use any of its fields,
+// e.g. {{.FullName}} or {{.StarredAt | date "2006-01-02"}}, along with these
+// helpers:
+//
+//	truncate N       shorten to N characters, adding an ellipsis
+//	date LAYOUT      format a time using a Go layout
+//	default VALUE    use VALUE if the input is empty
+//	join SEP         join a list, such as .Topics
+//	replace OLD NEW  replace every OLD with NEW
+//	lower, upper, trim
+//
+// The template is executed against a sample repository before it is
+// returned, so errors surface immediately rather than part way through an
+// export.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	if err := tmpl.Execute(io.Discard, sampleRepo); err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	return tmpl, nil
+}
+
+// ExecuteTemplate renders tmpl for the given repository.
+func ExecuteTemplate(tmpl *template.Template, repo github.StarredRepo) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, repo); err != nil {
+		return "", fmt.Errorf("failed to render %s template for %s: %w", tmpl.Name(), repo.FullName, err)
+	}
+
+	return b.String(), nil
+}
+
+// truncate shortens s to at most n characters, ending with an ellipsis if
+// anything was cut.
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n < 1 || len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n-1]) + "…"
+}
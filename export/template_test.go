@@ -0,0 +1,120 @@
+package export
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/monooso/gitboard/github"
+)
+
+// Test ParseTemplate renders repository fields and helper functions.
+func TestParseTemplate(t *testing.T) {
+	repo := github.StarredRepo{
+		FullName:    "golang/go",
+		HTMLURL:     "https://github.com/golang/go",
+		Description: "The Go programming language",
+		Topics:      []string{"go", "language"},
+		StarredAt:   time.Date(2023, 1, 15, 10, 30, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		repo     github.StarredRepo
+		expected string
+	}{
+		{name: "field", text: "{{.FullName}}", repo: repo, expected: "golang/go"},
+		{name: "date", text: `{{.StarredAt | date "2006-01-02"}}`, repo: repo, expected: "2023-01-15"},
+		{name: "truncate", text: "{{.Description | truncate 10}}", repo: repo, expected: "The Go pr…"},
+		{name: "truncate short", text: "{{.FullName | truncate 50}}", repo: repo, expected: "golang/go"},
+		{name: "join", text: `{{.Topics | join ", "}}`, repo: repo, expected: "go, language"},
+		{name: "upper", text: "{{.FullName | upper}}", repo: repo, expected: "GOLANG/GO"},
+		{
+			name:     "default",
+			text:     `{{.Description | default "No description"}}`,
+			repo:     github.StarredRepo{FullName: "a/b"},
+			expected: "No description",
+		},
+		{
+			name:     "combined",
+			text:     `{{.FullName}}: {{.Description}} (starred {{.StarredAt | date "Jan 2006"}})`,
+			repo:     repo,
+			expected: "golang/go: The Go programming language (starred Jan 2023)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate("test", tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			result, err := ExecuteTemplate(tmpl, tt.repo)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+// Test ParseTemplate rejects mistakes before an export starts.
+func TestParseTemplateInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "syntax", text: "{{.FullName"},
+		{name: "unknown field", text: "{{.Stars}}"},
+		{name: "unknown function", text: "{{.FullName | shout}}"},
+		{name: "wrong argument type", text: "{{.FullName | date \"2006\"}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTemplate("title", tt.text)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), "invalid title template") {
+				t.Errorf("expected error to name the template, got %q", err.Error())
+			}
+		})
+	}
+}
+
+// Test Run uses the title and description templates.
+func TestRunTemplates(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Description: "Tool"},
+	}}
+	pbClient := &mockPinboardClient{}
+	exporter := NewExporter(ghClient, pbClient)
+
+	var err error
+	exporter.TitleTemplate, err = ParseTemplate("title", "GitHub: {{.FullName}}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exporter.DescriptionTemplate, err = ParseTemplate("description", "{{.Description}} ({{.HTMLURL}})")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b := pbClient.addedBookmarks[0]
+	if b.Title != "GitHub: a/b" {
+		t.Errorf("expected templated title, got %q", b.Title)
+	}
+	if b.Description != "Tool (https://github.com/a/b)" {
+		t.Errorf("expected templated description, got %q", b.Description)
+	}
+}
@@ -0,0 +1,189 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/monooso/gitboard/github"
+	"github.com/monooso/gitboard/pinboard"
+)
+
+// URLStrategy determines which URL a repository is bookmarked under.
+type URLStrategy string
+
+const (
+	// URLRepo bookmarks the repository page on GitHub. This is the default.
+	URLRepo URLStrategy = ""
+	// URLHomepage bookmarks the project homepage, falling back to the
+	// repository page for repositories without one.
+	URLHomepage URLStrategy = "homepage"
+	// URLRepoWithHomepage bookmarks the repository page and adds the
+	// homepage to the description.
+	URLRepoWithHomepage URLStrategy = "repo-with-homepage"
+)
+
+// ParseURLStrategy converts a strategy name to a URLStrategy. An empty string
+// or "repo" selects URLRepo.
+func ParseURLStrategy(s string) (URLStrategy, error) {
+	switch u := URLStrategy(s); u {
+	case URLHomepage, URLRepoWithHomepage:
+		return u, nil
+	case URLRepo, "repo":
+		return URLRepo, nil
+	default:
+		return URLRepo, fmt.Errorf("unknown URL strategy %q (want repo, homepage, or repo-with-homepage)", s)
+	}
+}
+
+// BookmarkURL returns the URL to bookmark repo under.
+func (s URLStrategy) BookmarkURL(repo github.StarredRepo) string {
+	if s == URLHomepage {
+		if homepage := Homepage(repo); homepage != "" {
+			return homepage
+		}
+	}
+	return repo.HTMLURL
+}
+
+// Homepage returns repo's homepage, or an empty string if it doesn't have
+// one that can be bookmarked. GitHub accepts anything as a homepage, so only
+// absolute http and https URLs that differ from the repository page count.
+func Homepage(repo github.StarredRepo) string {
+	u, err := url.Parse(repo.Homepage)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	if repo.Homepage == repo.HTMLURL {
+		return ""
+	}
+	return repo.Homepage
+}
+
+// RepoURLs returns every URL that repo may be bookmarked under, whichever
+// strategy was in use at the time.
+func RepoURLs(repo github.StarredRepo) []string {
+	urls := []string{repo.HTMLURL}
+	if homepage := Homepage(repo); homepage != "" {
+		urls = append(urls, homepage)
+	}
+	return urls
+}
+
+// CanonicalURL returns the canonical form of a GitHub repository URL, so
+// that different spellings of it can be compared: https, lower case, no www.
+// prefix, no trailing slash or .git suffix, and no query string or fragment.
+// Other URLs, including deeper pages of a repository, are returned unchanged.
+func CanonicalURL(raw string) string {
+	owner, name, ok := ParseRepoURL(raw)
+	if !ok {
+		return raw
+	}
+
+	u, _ := url.Parse(strings.TrimSpace(raw))
+	if strings.Count(strings.Trim(u.Path, "/"), "/") != 1 {
+		return raw
+	}
+
+	return "https://github.com/" + strings.ToLower(owner+"/"+name)
+}
+
+// urlVariants groups existing bookmark URLs by their canonical form, so the
+// bookmarks for a repository can be found however their URLs are spelt.
+func urlVariants(existing map[string]bool) map[string][]string {
+	variants := make(map[string][]string, len(existing))
+	for u := range existing {
+		key := CanonicalURL(u)
+		variants[key] = append(variants[key], u)
+	}
+	for _, urls := range variants {
+		sort.Strings(urls)
+	}
+	return variants
+}
+
+// alternateURL returns the first URL that repo may have been bookmarked
+// under, other than want, that exists in Pinboard under any spelling.
+func alternateURL(repo github.StarredRepo, want string, variants map[string][]string) string {
+	for _, u := range RepoURLs(repo) {
+		for _, v := range variants[CanonicalURL(u)] {
+			if v != want {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// findDuplicates returns the sorted URLs of existing bookmarks that belong to
+// one of the given repositories only once their URLs are canonicalised, such
+// as http://github.com/Owner/Repo/ for https://github.com/owner/repo.
+func findDuplicates(repos []github.StarredRepo, existing map[string]bool) []string {
+	generated := make(map[string]bool, len(repos))
+	canonical := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		for _, u := range RepoURLs(repo) {
+			generated[u] = true
+			canonical[CanonicalURL(u)] = true
+		}
+	}
+
+	var duplicates []string
+	for u := range existing {
+		if !generated[u] && canonical[CanonicalURL(u)] {
+			duplicates = append(duplicates, u)
+		}
+	}
+	sort.Strings(duplicates)
+
+	return duplicates
+}
+
+// move bookmarks the task's repository under its new URL and deletes the
+// bookmark at its old one, keeping what the merge strategy says to keep. A
+// renamed repository's bookmark also keeps its tags, apart from generated
+// ones the repository no longer has. The new bookmark is written first, so
+// nothing is lost if the delete fails.
+func (e *Exporter) move(ctx context.Context, task Task) error {
+	current, err := e.pb.GetBookmark(ctx, task.OldURL)
+	if errors.Is(err, pinboard.ErrNotFound) {
+		// Already moved, perhaps by an interrupted run.
+		return e.pb.AddBookmark(ctx, task.Bookmark)
+	}
+	if err != nil {
+		return err
+	}
+
+	previous := e.generatedTags(task.OldURL)
+
+	var moved pinboard.Bookmark
+	switch e.Merge {
+	case MergeMerge:
+		moved = MergeBookmark(current, task.Bookmark, previous)
+	case MergeNever:
+		moved = current
+		moved.URL = task.URL
+	default:
+		moved = task.Bookmark
+		moved.Private = current.Private
+		moved.ToRead = current.ToRead
+	}
+	if task.Action == ActionRename {
+		moved.Tags = mergeTags(moved.Tags, current.Tags, previous)
+	}
+
+	if err := e.pb.AddBookmark(ctx, moved); err != nil {
+		return err
+	}
+	if err := e.pb.DeleteBookmark(ctx, task.OldURL); err != nil {
+		return err
+	}
+
+	if e.State != nil {
+		e.State.Remove(task.OldURL)
+	}
+	return nil
+}
@@ -0,0 +1,513 @@
+package export
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/monooso/gitboard/github"
+	"github.com/monooso/gitboard/pinboard"
+	"github.com/monooso/gitboard/state"
+)
+
+// Test ParseURLStrategy accepts known strategies and rejects others.
+func TestParseURLStrategy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected URLStrategy
+		wantErr  bool
+	}{
+		{input: "", expected: URLRepo},
+		{input: "repo", expected: URLRepo},
+		{input: "homepage", expected: URLHomepage},
+		{input: "repo-with-homepage", expected: URLRepoWithHomepage},
+		{input: "website", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			strategy, err := ParseURLStrategy(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strategy != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, strategy)
+			}
+		})
+	}
+}
+
+// Test BookmarkURL picks the homepage only when it is usable.
+func TestBookmarkURL(t *testing.T) {
+	const repoURL = "https://github.com/a/b"
+
+	tests := []struct {
+		name     string
+		strategy URLStrategy
+		homepage string
+		expected string
+	}{
+		{name: "repo", strategy: URLRepo, homepage: "https://b.dev", expected: repoURL},
+		{name: "homepage", strategy: URLHomepage, homepage: "https://b.dev", expected: "https://b.dev"},
+		{name: "no homepage", strategy: URLHomepage, homepage: "", expected: repoURL},
+		{name: "no scheme", strategy: URLHomepage, homepage: "b.dev", expected: repoURL},
+		{name: "not http", strategy: URLHomepage, homepage: "ftp://b.dev", expected: repoURL},
+		{name: "homepage is repo", strategy: URLHomepage, homepage: repoURL, expected: repoURL},
+		{name: "repo with homepage", strategy: URLRepoWithHomepage, homepage: "https://b.dev", expected: repoURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := github.StarredRepo{HTMLURL: repoURL, Homepage: tt.homepage}
+			if got := tt.strategy.BookmarkURL(repo); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// Test Run bookmarks homepages, falling back to the repo page when two
+// repos share a homepage.
+func TestRunURLStrategyHomepage(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/one", HTMLURL: "https://github.com/a/one", Homepage: "https://a.dev"},
+		{FullName: "a/two", HTMLURL: "https://github.com/a/two", Homepage: "https://a.dev"},
+		{FullName: "a/bare", HTMLURL: "https://github.com/a/bare"},
+	}}
+	pbClient := &mockPinboardClient{}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.URLStrategy = URLHomepage
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var urls []string
+	for _, b := range pbClient.addedBookmarks {
+		urls = append(urls, b.URL)
+	}
+	expected := "https://a.dev https://github.com/a/two https://github.com/a/bare"
+	if got := strings.Join(urls, " "); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+// Test Run adds the homepage to the description for repo-with-homepage.
+func TestRunURLStrategyRepoWithHomepage(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Description: "A tool", Homepage: "https://b.dev"},
+		{FullName: "a/c", HTMLURL: "https://github.com/a/c", Homepage: "https://c.dev"},
+	}}
+	pbClient := &mockPinboardClient{}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.URLStrategy = URLRepoWithHomepage
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pbClient.addedBookmarks) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %d", len(pbClient.addedBookmarks))
+	}
+	b := pbClient.addedBookmarks[0]
+	if b.URL != "https://github.com/a/b" || b.Description != "A tool\n\nHomepage: https://b.dev" {
+		t.Errorf("unexpected bookmark %+v", b)
+	}
+	if got := pbClient.addedBookmarks[1].Description; got != "Homepage: https://c.dev" {
+		t.Errorf("unexpected description %q", got)
+	}
+}
+
+// Test switching strategy leaves existing bookmarks alone instead of
+// creating duplicates, and doesn't treat them as orphans.
+func TestRunURLStrategySwitch(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Homepage: "https://b.dev"},
+	}}
+	pbClient := &mockPinboardClient{existingURLs: map[string]bool{"https://github.com/a/b": true}}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.URLStrategy = URLHomepage
+	exporter.Prune = PruneReport
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Skipped != 1 || result.Added != 0 {
+		t.Errorf("expected 1 skipped, got %+v", result)
+	}
+	if len(result.Orphaned) != 0 {
+		t.Errorf("expected no orphans, got %v", result.Orphaned)
+	}
+	if len(pbClient.addedBookmarks) != 0 {
+		t.Errorf("expected no bookmarks added, got %v", pbClient.addedBookmarks)
+	}
+}
+
+// Test MigrateURLs moves bookmarks to the new URL, keeping their privacy and
+// read status.
+func TestRunMigrateURLs(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Homepage: "https://b.dev"},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{"https://github.com/a/b": true},
+		storedBookmarks: map[string]pinboard.Bookmark{
+			"https://github.com/a/b": {
+				URL:     "https://github.com/a/b",
+				Title:   "a/b",
+				Tags:    []string{"github-repo", "mine"},
+				Private: false,
+				ToRead:  true,
+			},
+		},
+	}
+	store := newTestStore(t)
+	store.Put("https://github.com/a/b", state.Entry{Name: "a/b"})
+
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.URLStrategy = URLHomepage
+	exporter.MigrateURLs = true
+	exporter.State = store
+
+	var actions []Action
+	exporter.OnProgress = func(p Progress) { actions = append(actions, p.Action) }
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Moved != 1 {
+		t.Errorf("expected 1 moved, got %+v", result)
+	}
+	if len(actions) != 1 || actions[0] != ActionMove {
+		t.Errorf("expected a move action, got %v", actions)
+	}
+	if len(pbClient.addedBookmarks) != 1 {
+		t.Fatalf("expected 1 bookmark added, got %d", len(pbClient.addedBookmarks))
+	}
+	moved := pbClient.addedBookmarks[0]
+	if moved.URL != "https://b.dev" || moved.Private || !moved.ToRead {
+		t.Errorf("unexpected moved bookmark %+v", moved)
+	}
+	if len(pbClient.deletedURLs) != 1 || pbClient.deletedURLs[0] != "https://github.com/a/b" {
+		t.Errorf("expected old bookmark deleted, got %v", pbClient.deletedURLs)
+	}
+	if _, ok := store.Get("https://github.com/a/b"); ok {
+		t.Error("expected old URL removed from state")
+	}
+	if _, ok := store.Get("https://b.dev"); !ok {
+		t.Error("expected new URL recorded in state")
+	}
+}
+
+// Test MigrateURLs with the merge strategy keeps user-added tags.
+func TestRunMigrateURLsMerge(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Homepage: "https://b.dev"},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{"https://b.dev": true},
+		storedBookmarks: map[string]pinboard.Bookmark{
+			"https://b.dev": {URL: "https://b.dev", Tags: []string{"github-repo", "mine"}},
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.MigrateURLs = true
+	exporter.Merge = MergeMerge
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(pbClient.addedBookmarks) != 1 {
+		t.Fatalf("expected 1 bookmark added, got %d", len(pbClient.addedBookmarks))
+	}
+	moved := pbClient.addedBookmarks[0]
+	if moved.URL != "https://github.com/a/b" {
+		t.Errorf("expected move to the repo page, got %q", moved.URL)
+	}
+	if got := strings.Join(moved.Tags, " "); got != "github-repo mine" {
+		t.Errorf("expected user tags kept, got %q", got)
+	}
+}
+
+// Test MigrateURLs changes nothing in a dry run.
+func TestRunMigrateURLsDryRun(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "a/b", HTMLURL: "https://github.com/a/b", Homepage: "https://b.dev"},
+	}}
+	pbClient := &mockPinboardClient{existingURLs: map[string]bool{"https://github.com/a/b": true}}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.URLStrategy = URLHomepage
+	exporter.MigrateURLs = true
+	exporter.DryRun = true
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Moved != 1 {
+		t.Errorf("expected 1 move, got %+v", result)
+	}
+	if len(pbClient.addedBookmarks) != 0 || len(pbClient.deletedURLs) != 0 {
+		t.Error("expected no changes in a dry run")
+	}
+}
+
+// Test CanonicalURL folds the spellings of a GitHub repository URL together.
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "https://github.com/owner/repo", expected: "https://github.com/owner/repo"},
+		{input: "http://github.com/Owner/Repo/", expected: "https://github.com/owner/repo"},
+		{input: "https://www.github.com/owner/repo", expected: "https://github.com/owner/repo"},
+		{input: "HTTPS://GitHub.com/owner/repo.git", expected: "https://github.com/owner/repo"},
+		{input: "https://github.com/owner/repo?tab=readme-ov-file#usage", expected: "https://github.com/owner/repo"},
+		{input: " https://github.com/owner/repo/ ", expected: "https://github.com/owner/repo"},
+		{input: "https://github.com/owner/repo/issues", expected: "https://github.com/owner/repo/issues"},
+		{input: "https://github.com/owner", expected: "https://github.com/owner"},
+		{input: "https://github.com/topics/go", expected: "https://github.com/topics/go"},
+		{input: "https://Example.com/Docs/", expected: "https://Example.com/Docs/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := CanonicalURL(tt.input); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// Test Run recognises bookmarks saved under another spelling of the repo URL,
+// leaving them alone and reporting them instead of adding duplicates.
+func TestRunCanonicalURLs(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "owner/repo", HTMLURL: "https://github.com/owner/repo"},
+		{FullName: "owner/other", HTMLURL: "https://github.com/owner/other"},
+	}}
+	pbClient := &mockPinboardClient{existingURLs: map[string]bool{
+		"http://github.com/Owner/Repo/":      true,
+		"https://github.com/owner/other":     true,
+		"https://github.com/owner/other.git": true,
+	}}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.Prune = PruneReport
+
+	var urls []string
+	exporter.OnProgress = func(p Progress) { urls = append(urls, p.URL) }
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Skipped != 2 || result.Added != 0 {
+		t.Errorf("expected 2 skipped, got %+v", result)
+	}
+	if len(urls) != 2 || urls[0] != "http://github.com/Owner/Repo/" {
+		t.Errorf("expected the existing spelling to be kept, got %v", urls)
+	}
+	if len(result.Orphaned) != 0 {
+		t.Errorf("expected no orphans, got %v", result.Orphaned)
+	}
+
+	expected := []string{"http://github.com/Owner/Repo/", "https://github.com/owner/other.git"}
+	if strings.Join(result.Duplicates, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected duplicates %v, got %v", expected, result.Duplicates)
+	}
+}
+
+// Test an incremental run recognises a new star that was already bookmarked
+// under another spelling, rather than adding a second bookmark.
+func TestRunIncrementalCanonicalURLs(t *testing.T) {
+	lastRun := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "Owner/Repo", HTMLURL: "https://github.com/Owner/Repo", StarredAt: lastRun.Add(time.Hour)},
+	}}
+	pbClient := &mockPinboardClient{existingURLs: map[string]bool{
+		"http://github.com/owner/repo/": true,
+	}}
+	store := newTestStore(t)
+	store.LastRun = lastRun
+
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.State = store
+	exporter.Incremental = true
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ghClient.since.Equal(lastRun) {
+		t.Errorf("expected an incremental run, got since=%v", ghClient.since)
+	}
+	if result.Skipped != 1 || result.Added != 0 {
+		t.Errorf("expected 1 skipped, got %+v", result)
+	}
+	if len(pbClient.addedBookmarks) != 0 {
+		t.Errorf("expected no bookmarks added, got %v", pbClient.addedBookmarks)
+	}
+	if len(result.Duplicates) != 1 || result.Duplicates[0] != "http://github.com/owner/repo/" {
+		t.Errorf("expected the existing spelling listed, got %v", result.Duplicates)
+	}
+}
+
+// Test MigrateURLs moves bookmarks saved under another spelling to the
+// repository's URL.
+func TestRunMigrateURLsCanonical(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{FullName: "owner/repo", HTMLURL: "https://github.com/owner/repo"},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{"http://www.github.com/owner/repo": true},
+		storedBookmarks: map[string]pinboard.Bookmark{
+			"http://www.github.com/owner/repo": {URL: "http://www.github.com/owner/repo", Title: "repo"},
+		},
+	}
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.MigrateURLs = true
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Moved != 1 {
+		t.Errorf("expected 1 moved, got %+v", result)
+	}
+	if len(pbClient.addedBookmarks) != 1 || pbClient.addedBookmarks[0].URL != "https://github.com/owner/repo" {
+		t.Errorf("expected the bookmark at the repo URL, got %v", pbClient.addedBookmarks)
+	}
+	if len(pbClient.deletedURLs) != 1 || pbClient.deletedURLs[0] != "http://www.github.com/owner/repo" {
+		t.Errorf("expected the old spelling deleted, got %v", pbClient.deletedURLs)
+	}
+}
+
+// Test Run follows a renamed repository, moving its bookmark to the new URL
+// and keeping its tags, rather than adding a duplicate.
+func TestRunFollowsRename(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{ID: 42, FullName: "new-owner/new-name", HTMLURL: "https://github.com/new-owner/new-name", Topics: []string{"go"}},
+	}}
+	pbClient := &mockPinboardClient{
+		existingURLs: map[string]bool{"https://github.com/old-owner/old-name": true},
+		storedBookmarks: map[string]pinboard.Bookmark{
+			"https://github.com/old-owner/old-name": {
+				URL:    "https://github.com/old-owner/old-name",
+				Title:  "old-owner/old-name",
+				Tags:   []string{"github-repo", "go", "mine"},
+				ToRead: true,
+			},
+		},
+	}
+	store := newTestStore(t)
+	store.Put("https://github.com/old-owner/old-name", state.Entry{Name: "old-owner/old-name", RepoID: 42})
+
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.State = store
+	exporter.Prune = PruneDelete
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Renamed != 1 || result.Added != 0 {
+		t.Errorf("expected 1 renamed, got %+v", result)
+	}
+	if len(result.Orphaned) != 0 {
+		t.Errorf("expected the old bookmark not to be an orphan, got %v", result.Orphaned)
+	}
+
+	if len(pbClient.addedBookmarks) != 1 {
+		t.Fatalf("expected 1 bookmark added, got %d", len(pbClient.addedBookmarks))
+	}
+	moved := pbClient.addedBookmarks[0]
+	if moved.URL != "https://github.com/new-owner/new-name" || moved.Title != "new-owner/new-name" || !moved.ToRead {
+		t.Errorf("unexpected moved bookmark %+v", moved)
+	}
+	if got := strings.Join(moved.Tags, " "); got != "github-repo go mine" {
+		t.Errorf("expected tags kept, got %q", got)
+	}
+	if len(pbClient.deletedURLs) != 1 || pbClient.deletedURLs[0] != "https://github.com/old-owner/old-name" {
+		t.Errorf("expected old bookmark deleted, got %v", pbClient.deletedURLs)
+	}
+
+	if _, ok := store.Get("https://github.com/old-owner/old-name"); ok {
+		t.Error("expected old URL removed from state")
+	}
+	if entry, ok := store.Get("https://github.com/new-owner/new-name"); !ok || entry.RepoID != 42 {
+		t.Errorf("expected new URL recorded with the repo ID, got %+v", entry)
+	}
+}
+
+// Test Run adds a renamed repository normally if its old bookmark has gone.
+func TestRunRenameWithoutBookmark(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{ID: 42, FullName: "owner/new", HTMLURL: "https://github.com/owner/new"},
+	}}
+	pbClient := &mockPinboardClient{}
+	store := newTestStore(t)
+	store.Put("https://github.com/owner/old", state.Entry{Name: "owner/old", RepoID: 42})
+
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.State = store
+
+	result, err := exporter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Added != 1 || result.Renamed != 0 {
+		t.Errorf("expected 1 added, got %+v", result)
+	}
+	if len(pbClient.deletedURLs) != 0 {
+		t.Errorf("expected nothing deleted, got %v", pbClient.deletedURLs)
+	}
+}
+
+// Test Run follows a rename in a dry run without touching Pinboard or state.
+func TestRunRenameDryRun(t *testing.T) {
+	ghClient := &mockGitHubClient{repos: []github.StarredRepo{
+		{ID: 42, FullName: "owner/new", HTMLURL: "https://github.com/owner/new"},
+	}}
+	pbClient := &mockPinboardClient{existingURLs: map[string]bool{"https://github.com/owner/old": true}}
+	store := newTestStore(t)
+	store.Put("https://github.com/owner/old", state.Entry{Name: "owner/old", RepoID: 42})
+
+	exporter := NewExporter(ghClient, pbClient)
+	exporter.State = store
+	exporter.DryRun = true
+
+	var actions []Action
+	exporter.OnProgress = func(p Progress) { actions = append(actions, p.Action) }
+
+	if _, err := exporter.Run(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(actions) != 1 || actions[0] != ActionRename {
+		t.Errorf("expected a rename action, got %v", actions)
+	}
+	if len(pbClient.addedBookmarks) != 0 || len(pbClient.deletedURLs) != 0 {
+		t.Errorf("expected Pinboard untouched, got %v %v", pbClient.addedBookmarks, pbClient.deletedURLs)
+	}
+	if _, ok := store.Get("https://github.com/owner/old"); !ok {
+		t.Error("expected state untouched")
+	}
+}
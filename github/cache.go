@@ -0,0 +1,164 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultCacheSize is the default limit on the size of a Cache, in bytes.
+const DefaultCacheSize = 100 << 20
+
+// Cache stores GitHub API responses on disk, so they can be revalidated with
+// conditional requests. GitHub doesn't count a 304 Not Modified response
+// against the rate limit.
+type Cache struct {
+	dir string
+
+	// MaxSize caps the total size of the cached responses, in bytes. When it
+	// is exceeded the least recently stored responses are removed. Zero
+	// means no limit.
+	MaxSize int64
+}
+
+// cacheEntry is a cached response.
+type cacheEntry struct {
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	Link         string          `json:"link,omitempty"`
+	Body         json.RawMessage `json:"body"`
+}
+
+// NewCache creates a cache in dir, limited to DefaultCacheSize. The directory
+// is created when the first response is stored.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir, MaxSize: DefaultCacheSize}
+}
+
+// DefaultCacheDir returns the default cache directory, following the XDG base
+// directory specification: $XDG_CACHE_HOME/gitboard/github, falling back to
+// ~/.cache/gitboard/github.
+func DefaultCacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "gitboard", "github"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".cache", "gitboard", "github"), nil
+}
+
+// Clear removes every cached response.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
+
+// path returns the file holding the response for url. Responses depend on who
+// is asking, so the token is part of the key. It is hashed, never stored.
+func (c *Cache) path(token, url string) string {
+	sum := sha256.Sum256([]byte(token + "\n" + url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// get returns the cached response for url, if there is one. An unreadable
+// entry is treated as missing.
+func (c *Cache) get(token, url string) (cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(token, url))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// put stores the response for url, then trims the cache to MaxSize.
+func (c *Cache) put(token, url string, entry cacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file and rename, so a crash can't leave a
+	// truncated entry behind.
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path(token, url)); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return c.trim()
+}
+
+// trim removes the oldest entries until the cache fits in MaxSize.
+func (c *Cache) trim() error {
+	if c.MaxSize <= 0 {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list cache: %w", err)
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []file
+	var total int64
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		files = append(files, file{path, info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	for _, f := range files {
+		if total <= c.MaxSize {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to trim cache: %w", err)
+		}
+		total -= f.size
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCachePutAndGet tests that entries round-trip and are keyed by token.
+func TestCachePutAndGet(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"))
+	entry := cacheEntry{ETag: `"abc"`, Link: `<next>; rel="next"`, Body: []byte(`[]`)}
+
+	if err := cache.put("token-a", "https://api.github.com/user/starred", entry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, ok := cache.get("token-a", "https://api.github.com/user/starred")
+	if !ok {
+		t.Fatal("expected a cached entry")
+	}
+	if got.ETag != entry.ETag || got.Link != entry.Link || string(got.Body) != "[]" {
+		t.Errorf("unexpected entry %+v", got)
+	}
+
+	if _, ok := cache.get("token-b", "https://api.github.com/user/starred"); ok {
+		t.Error("expected no entry for a different token")
+	}
+}
+
+// TestCacheTrim tests that the oldest entries are removed beyond MaxSize.
+func TestCacheTrim(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewCache(dir)
+	body := []byte(`"` + strings.Repeat("x", 100) + `"`)
+
+	for i, url := range []string{"/one", "/two", "/three"} {
+		if err := cache.put("", url, cacheEntry{ETag: "e", Body: body}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Make the store order unambiguous.
+		old := time.Now().Add(time.Duration(i-10) * time.Minute)
+		os.Chtimes(cache.path("", url), old, old)
+	}
+
+	info, err := os.Stat(cache.path("", "/one"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cache.MaxSize = 2 * info.Size()
+	if err := cache.trim(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := cache.get("", "/one"); ok {
+		t.Error("expected the oldest entry to be removed")
+	}
+	for _, url := range []string{"/two", "/three"} {
+		if _, ok := cache.get("", url); !ok {
+			t.Errorf("expected %s to be kept", url)
+		}
+	}
+}
+
+// TestCacheClear tests that clearing removes every entry.
+func TestCacheClear(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"))
+	cache.put("", "/one", cacheEntry{ETag: "e", Body: []byte(`[]`)})
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cache.get("", "/one"); ok {
+		t.Error("expected no entries after clearing")
+	}
+
+	// Clearing an empty cache is fine.
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestDefaultCacheDirUsesXDGCacheHome tests that XDG_CACHE_HOME is respected.
+func TestDefaultCacheDirUsesXDGCacheHome(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := filepath.Join("/tmp/xdg-cache", "gitboard", "github"); dir != expected {
+		t.Errorf("expected %q, got %q", expected, dir)
+	}
+}
+
+// TestGetStarredRepos_ConditionalRequests tests that cached pages are
+// revalidated and reused on 304 Not Modified, including their Link headers.
+func TestGetStarredRepos_ConditionalRequests(t *testing.T) {
+	var serverURL string
+	var conditional []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		etag := `"page-` + page + `"`
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", etag)
+		if page == "" {
+			w.Header().Set("Link", `<`+serverURL+`/user/starred?page=2>; rel="next"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{
+			"starred_at": "2024-01-01T00:00:00Z",
+			"repo": {"full_name": "owner/page` + page + `", "html_url": "https://github.com/owner/page` + page + `"}
+		}]`))
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+	client.Cache = NewCache(t.TempDir())
+
+	for run := 0; run < 2; run++ {
+		repos, err := client.GetStarredRepos(context.Background())
+		if err != nil {
+			t.Fatalf("run %d: unexpected error: %v", run, err)
+		}
+		if len(repos) != 2 || repos[1].FullName != "owner/page2" {
+			t.Errorf("run %d: expected both pages, got %v", run, repos)
+		}
+	}
+
+	expected := []string{"", "", `"page-"`, `"page-2"`}
+	if strings.Join(conditional, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected If-None-Match headers %v, got %v", expected, conditional)
+	}
+}
@@ -4,18 +4,44 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	neturl "net/url"
 	"strings"
 	"time"
 )
 
 // StarredRepo represents a GitHub repository that has been starred.
 type StarredRepo struct {
+	// ID is GitHub's numeric repository ID. Unlike the name and URL, it
+	// doesn't change when the repository is renamed or transferred.
+	ID int64
+
 	FullName    string
 	HTMLURL     string
 	Description string
 	Topics      []string
 	StarredAt   time.Time
+
+	Language      string // primary language
+	Stargazers    int
+	Forks         int
+	Archived      bool
+	Disabled      bool
+	Fork          bool
+	Homepage      string
+	License       string // SPDX identifier
+	DefaultBranch string
+	PushedAt      time.Time
+	UpdatedAt     time.Time
+
+	// Parent is the full name of the repository this is a fork of. Only
+	// GraphQLClient fills it in; the REST API doesn't provide it here.
+	Parent string
+
+	// Lists holds the names of the user's star lists that include the
+	// repository. The REST API doesn't provide these; see GetStarLists.
+	Lists []string
 }
 
 // Client is a GitHub API client.
@@ -23,14 +49,37 @@ type Client struct {
 	token      string
 	baseURL    string
 	httpClient *http.Client
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) error
+
+	// MaxRateLimitWait is the longest the client will wait for a rate limit
+	// to reset before trying again. If the reset is further away, the
+	// request fails with a RateLimitError. Zero never waits.
+	MaxRateLimitWait time.Duration
+
+	// OnRateLimit, if set, is called before waiting for a rate limit to
+	// reset.
+	OnRateLimit func(*RateLimitError)
+
+	// Cache, if set, stores pages of starred repositories so later
+	// requests for them can be conditional.
+	Cache *Cache
+
+	// User, if set, makes GetStarredRepos fetch that user's public stars
+	// instead of the authenticated user's. No token is needed in that case,
+	// but unauthenticated requests are subject to much lower rate limits.
+	User string
 }
 
-// NewClient creates a new GitHub API client with the given token.
+// NewClient creates a new GitHub API client with the given token. The token
+// may be empty when only reading a User's public stars.
 func NewClient(token string) *Client {
 	return &Client{
 		token:      token,
 		baseURL:    "https://api.github.com",
 		httpClient: &http.Client{},
+		now:        time.Now,
+		sleep:      sleep,
 	}
 }
 
@@ -39,45 +88,56 @@ func NewClient(token string) *Client {
 type starredRepoResponse struct {
 	StarredAt string `json:"starred_at"`
 	Repo      struct {
-		FullName    string   `json:"full_name"`
-		HTMLURL     string   `json:"html_url"`
-		Description string   `json:"description"`
-		Topics      []string `json:"topics"`
+		ID              int64    `json:"id"`
+		FullName        string   `json:"full_name"`
+		HTMLURL         string   `json:"html_url"`
+		Description     string   `json:"description"`
+		Topics          []string `json:"topics"`
+		Language        string   `json:"language"`
+		StargazersCount int      `json:"stargazers_count"`
+		ForksCount      int      `json:"forks_count"`
+		Archived        bool     `json:"archived"`
+		Disabled        bool     `json:"disabled"`
+		Fork            bool     `json:"fork"`
+		Homepage        string   `json:"homepage"`
+		License         *struct {
+			SPDXID string `json:"spdx_id"`
+		} `json:"license"`
+		DefaultBranch string    `json:"default_branch"`
+		PushedAt      time.Time `json:"pushed_at"`
+		UpdatedAt     time.Time `json:"updated_at"`
 	} `json:"repo"`
 }
 
-// GetStarredRepos fetches all starred repositories for the authenticated user.
+// GetStarredRepos fetches all starred repositories for the authenticated user,
+// or for User if it is set.
 // It handles pagination automatically, following Link headers until all pages
 // have been retrieved.
 func (c *Client) GetStarredRepos(ctx context.Context) ([]StarredRepo, error) {
+	return c.GetStarredReposSince(ctx, time.Time{})
+}
+
+// GetStarredReposSince fetches the repositories starred at or after since,
+// newest first. GitHub returns stars in reverse chronological order, so paging
+// stops as soon as an older star is seen. A zero since fetches everything.
+func (c *Client) GetStarredReposSince(ctx context.Context, since time.Time) ([]StarredRepo, error) {
 	var allRepos []StarredRepo
-	url := fmt.Sprintf("%s/user/starred?per_page=100", c.baseURL)
+	url := fmt.Sprintf("%s/user/starred?per_page=100&sort=created&direction=desc", c.baseURL)
+	if c.User != "" {
+		url = fmt.Sprintf("%s/users/%s/starred?per_page=100&sort=created&direction=desc", c.baseURL, neturl.PathEscape(c.User))
+	}
 
+pages:
 	for url != "" {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		body, link, err := c.getPage(ctx, url)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-
-		req.Header.Set("Accept", "application/vnd.github.star+json")
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
-
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("request failed: %w", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			return nil, fmt.Errorf("GitHub API request failed with status %d", resp.StatusCode)
+			return nil, err
 		}
 
 		var pageRepos []starredRepoResponse
-		if err := json.NewDecoder(resp.Body).Decode(&pageRepos); err != nil {
-			resp.Body.Close()
+		if err := json.Unmarshal(body, &pageRepos); err != nil {
 			return nil, fmt.Errorf("failed to decode response: %w", err)
 		}
-		resp.Body.Close()
 
 		// Convert API response to our domain model
 		for _, apiRepo := range pageRepos {
@@ -86,12 +146,31 @@ func (c *Client) GetStarredRepos(ctx context.Context) ([]StarredRepo, error) {
 				return nil, fmt.Errorf("failed to parse starred_at time: %w", err)
 			}
 
+			// Everything from here on was starred before the cut-off
+			if !since.IsZero() && starredAt.Before(since) {
+				break pages
+			}
+
 			repo := StarredRepo{
-				FullName:    apiRepo.Repo.FullName,
-				HTMLURL:     apiRepo.Repo.HTMLURL,
-				Description: apiRepo.Repo.Description,
-				Topics:      apiRepo.Repo.Topics,
-				StarredAt:   starredAt,
+				ID:            apiRepo.Repo.ID,
+				FullName:      apiRepo.Repo.FullName,
+				HTMLURL:       apiRepo.Repo.HTMLURL,
+				Description:   apiRepo.Repo.Description,
+				Topics:        apiRepo.Repo.Topics,
+				StarredAt:     starredAt,
+				Language:      apiRepo.Repo.Language,
+				Stargazers:    apiRepo.Repo.StargazersCount,
+				Forks:         apiRepo.Repo.ForksCount,
+				Archived:      apiRepo.Repo.Archived,
+				Disabled:      apiRepo.Repo.Disabled,
+				Fork:          apiRepo.Repo.Fork,
+				Homepage:      apiRepo.Repo.Homepage,
+				DefaultBranch: apiRepo.Repo.DefaultBranch,
+				PushedAt:      apiRepo.Repo.PushedAt,
+				UpdatedAt:     apiRepo.Repo.UpdatedAt,
+			}
+			if apiRepo.Repo.License != nil {
+				repo.License = apiRepo.Repo.License.SPDXID
 			}
 
 			// Ensure Topics is never nil, use empty slice instead
@@ -103,7 +182,7 @@ func (c *Client) GetStarredRepos(ctx context.Context) ([]StarredRepo, error) {
 		}
 
 		// Check for next page in Link header
-		url = extractNextURL(resp.Header.Get("Link"))
+		url = extractNextURL(link)
 	}
 
 	// Ensure we return an empty slice rather than nil
@@ -114,6 +193,77 @@ func (c *Client) GetStarredRepos(ctx context.Context) ([]StarredRepo, error) {
 	return allRepos, nil
 }
 
+// getPage fetches a page of starred repositories, returning its body and Link
+// header. If the client has a Cache, the request is made conditional on the
+// cached copy, which is used if GitHub reports it hasn't changed.
+func (c *Client) getPage(ctx context.Context, url string) ([]byte, string, error) {
+	var cached cacheEntry
+	var hit bool
+	if c.Cache != nil {
+		cached, hit = c.Cache.get(c.token, url)
+	}
+
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Accept", "application/vnd.github.star+json")
+		c.authorize(req)
+
+		if hit {
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hit {
+		return cached.Body, cached.Link, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("GitHub API request failed with status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read response: %w", err)
+	}
+	link := resp.Header.Get("Link")
+
+	if c.Cache != nil {
+		entry := cacheEntry{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			Link:         link,
+			Body:         body,
+		}
+		// A response that can't be revalidated isn't worth keeping. The
+		// cache is only an optimisation, so failing to write it is ignored.
+		if entry.ETag != "" || entry.LastModified != "" {
+			_ = c.Cache.put(c.token, url, entry)
+		}
+	}
+
+	return body, link, nil
+}
+
+// authorize adds the token to req, if the client has one.
+func (c *Client) authorize(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+}
+
 // extractNextURL parses the Link header and extracts the URL for the next page.
 // Returns an empty string if there is no next page.
 func extractNextURL(linkHeader string) string {
@@ -139,3 +289,30 @@ func extractNextURL(linkHeader string) string {
 
 	return ""
 }
+
+// StarRepo stars the given repository for the authenticated user. Starring a
+// repository that is already starred is not an error.
+func (c *Client) StarRepo(ctx context.Context, owner, repo string) error {
+	url := fmt.Sprintf("%s/user/starred/%s/%s", c.baseURL, owner, repo)
+
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Accept", "application/vnd.github+json")
+		c.authorize(req)
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("GitHub API request failed with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -108,6 +108,84 @@ func TestGetStarredRepos_SinglePage(t *testing.T) {
 	}
 }
 
+// TestGetStarredRepos_Metadata tests that the richer repository metadata in
+// the star+json payload is kept.
+func TestGetStarredRepos_Metadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{
+				"starred_at": "2023-01-15T10:30:00Z",
+				"repo": {
+					"id": 123456,
+					"full_name": "owner/full",
+					"html_url": "https://github.com/owner/full",
+					"language": "Go",
+					"stargazers_count": 1200,
+					"forks_count": 34,
+					"archived": true,
+					"disabled": false,
+					"fork": true,
+					"homepage": "https://full.example.com",
+					"license": {"key": "mit", "spdx_id": "MIT"},
+					"default_branch": "main",
+					"pushed_at": "2024-05-01T12:00:00Z",
+					"updated_at": "2024-05-02T08:00:00Z"
+				}
+			},
+			{
+				"starred_at": "2023-01-14T10:30:00Z",
+				"repo": {
+					"full_name": "owner/sparse",
+					"html_url": "https://github.com/owner/sparse",
+					"language": null,
+					"homepage": null,
+					"license": null,
+					"pushed_at": null
+				}
+			}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	repos, err := client.GetStarredRepos(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Fatalf("expected 2 repos, got %d", len(repos))
+	}
+
+	full := repos[0]
+	if full.ID != 123456 {
+		t.Errorf("expected ID 123456, got %d", full.ID)
+	}
+	if full.Language != "Go" || full.Stargazers != 1200 || full.Forks != 34 {
+		t.Errorf("unexpected language or counts %+v", full)
+	}
+	if !full.Archived || full.Disabled || !full.Fork {
+		t.Errorf("unexpected flags %+v", full)
+	}
+	if full.Homepage != "https://full.example.com" || full.License != "MIT" || full.DefaultBranch != "main" {
+		t.Errorf("unexpected homepage, licence or branch %+v", full)
+	}
+	if !full.PushedAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected PushedAt %v", full.PushedAt)
+	}
+	if !full.UpdatedAt.Equal(time.Date(2024, 5, 2, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected UpdatedAt %v", full.UpdatedAt)
+	}
+
+	sparse := repos[1]
+	if sparse.Language != "" || sparse.Homepage != "" || sparse.License != "" || !sparse.PushedAt.IsZero() {
+		t.Errorf("expected empty metadata for nulls, got %+v", sparse)
+	}
+}
+
 // TestGetStarredRepos_Pagination tests that the client follows pagination links.
 func TestGetStarredRepos_Pagination(t *testing.T) {
 	pageRequests := 0
@@ -233,3 +311,145 @@ func TestGetStarredRepos_HTTPError(t *testing.T) {
 		t.Errorf("expected error %q, got %q", expectedMsg, err.Error())
 	}
 }
+
+// TestGetStarredReposSince tests that paging stops at the first star older than the cut-off.
+func TestGetStarredReposSince(t *testing.T) {
+	pageRequests := 0
+	var serverURL string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pageRequests++
+
+		if got := r.URL.Query().Get("direction"); got != "desc" {
+			t.Errorf("expected direction=desc, got %s", got)
+		}
+
+		// Always advertise another page; the client should not follow it.
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Link", `<`+serverURL+`/user/starred?per_page=100&page=2>; rel="next"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{
+				"starred_at": "2024-03-01T00:00:00Z",
+				"repo": {"full_name": "owner/new", "html_url": "https://github.com/owner/new"}
+			},
+			{
+				"starred_at": "2023-06-01T00:00:00Z",
+				"repo": {"full_name": "owner/old", "html_url": "https://github.com/owner/old"}
+			}
+		]`))
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repos, err := client.GetStarredReposSince(context.Background(), since)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pageRequests != 1 {
+		t.Errorf("expected 1 page request, got %d", pageRequests)
+	}
+	if len(repos) != 1 || repos[0].FullName != "owner/new" {
+		t.Errorf("expected only owner/new, got %v", repos)
+	}
+}
+
+// TestGetStarredRepos_User tests fetching another user's public stars without a token.
+func TestGetStarredRepos_User(t *testing.T) {
+	var path, accept string
+	authSent := false
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		accept = r.Header.Get("Accept")
+		_, authSent = r.Header["Authorization"]
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{
+				"starred_at": "2024-03-01T00:00:00Z",
+				"repo": {"full_name": "owner/repo", "html_url": "https://github.com/owner/repo"}
+			}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("")
+	client.baseURL = server.URL
+	client.User = "octocat"
+
+	repos, err := client.GetStarredRepos(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path != "/users/octocat/starred" {
+		t.Errorf("expected path /users/octocat/starred, got %s", path)
+	}
+	if accept != "application/vnd.github.star+json" {
+		t.Errorf("expected star+json media type, got %s", accept)
+	}
+	if authSent {
+		t.Error("expected no Authorization header without a token")
+	}
+	if len(repos) != 1 || repos[0].FullName != "owner/repo" {
+		t.Errorf("expected owner/repo, got %v", repos)
+	}
+}
+
+// TestStarRepo tests that starring sends an authenticated PUT request.
+func TestStarRepo(t *testing.T) {
+	var method, path, auth string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	if err := client.StarRepo(context.Background(), "golang", "go"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if method != http.MethodPut {
+		t.Errorf("expected PUT request, got %s", method)
+	}
+	if path != "/user/starred/golang/go" {
+		t.Errorf("expected path /user/starred/golang/go, got %s", path)
+	}
+	if auth != "Bearer test-token" {
+		t.Errorf("expected Authorization header Bearer test-token, got %s", auth)
+	}
+}
+
+// TestStarRepo_HTTPError tests that a failed star returns the status code.
+func TestStarRepo_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	err := client.StarRepo(context.Background(), "nobody", "nothing")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	expectedMsg := "GitHub API request failed with status 404"
+	if err.Error() != expectedMsg {
+		t.Errorf("expected error %q, got %q", expectedMsg, err.Error())
+	}
+}
@@ -0,0 +1,316 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// graphQLRequest is the body of a GraphQL API request.
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// graphQLResponse is the envelope of a GraphQL API response.
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// queryOwner runs viewerQuery against the authenticated user, or userQuery
+// against User if it is set, and decodes the user object into out. userQuery
+// must take the login as $login.
+func (c *Client) queryOwner(ctx context.Context, viewerQuery, userQuery string, variables map[string]any, out any) error {
+	var data struct {
+		Viewer json.RawMessage `json:"viewer"`
+		User   json.RawMessage `json:"user"`
+	}
+
+	owner := &data.Viewer
+	query := viewerQuery
+	if c.User != "" {
+		owner = &data.User
+		query = userQuery
+		variables["login"] = c.User
+	}
+
+	if err := c.graphQL(ctx, query, variables, &data); err != nil {
+		return err
+	}
+	if len(*owner) == 0 || string(*owner) == "null" {
+		return fmt.Errorf("user %q not found", c.User)
+	}
+
+	if err := json.Unmarshal(*owner, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// pageInfo is the pagination information of a GraphQL connection.
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// graphQL sends a query to the GraphQL API and decodes the data it returns
+// into out. Unlike the REST API, the GraphQL API always needs a token.
+func (c *Client) graphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	if c.token == "" {
+		return errors.New("the GitHub GraphQL API requires a token")
+	}
+
+	body, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		envelope, limited, err := c.postGraphQL(ctx, body)
+		if err != nil {
+			return err
+		}
+		if limited == nil {
+			return envelope.decode(out)
+		}
+
+		if attempt == maxRateLimitAttempts {
+			return limited
+		}
+		if err := c.waitForReset(ctx, limited); err != nil {
+			return err
+		}
+	}
+}
+
+// postGraphQL sends an encoded GraphQL request. The GraphQL API reports its
+// primary rate limit with a 200 response, so that is returned as a
+// RateLimitError rather than an error, for the caller to wait on.
+func (c *Client) postGraphQL(ctx context.Context, body []byte) (graphQLResponse, *RateLimitError, error) {
+	resp, err := c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/graphql", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		c.authorize(req)
+		return req, nil
+	})
+	if err != nil {
+		return graphQLResponse{}, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return graphQLResponse{}, nil, fmt.Errorf("GitHub API request failed with status %d", resp.StatusCode)
+	}
+
+	var envelope graphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return graphQLResponse{}, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for _, e := range envelope.Errors {
+		if e.Type == "RATE_LIMITED" {
+			limited := &RateLimitError{Reset: c.now().Add(secondaryRateLimitWait)}
+			if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+				limited.Reset = time.Unix(reset, 0)
+			}
+			return envelope, limited, nil
+		}
+	}
+
+	return envelope, nil, nil
+}
+
+// decode returns the response's errors, if any, or decodes its data into out.
+func (envelope graphQLResponse) decode(out any) error {
+	if len(envelope.Errors) > 0 {
+		messages := make([]string, len(envelope.Errors))
+		for i, e := range envelope.Errors {
+			messages[i] = e.Message
+		}
+		return fmt.Errorf("GitHub GraphQL query failed: %s", strings.Join(messages, "; "))
+	}
+
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// GraphQLClient is a Client that fetches starred repositories through the
+// GraphQL API instead of REST. It takes the same number of requests, one per
+// 100 stars, and also fills in each repository's fork parent. Unlike Client,
+// it always needs a token.
+type GraphQLClient struct {
+	*Client
+}
+
+// NewGraphQLClient creates a new GitHub GraphQL API client with the given
+// token.
+func NewGraphQLClient(token string) *GraphQLClient {
+	return &GraphQLClient{Client: NewClient(token)}
+}
+
+// starredFields selects a page of starred repositories, newest first.
+const starredFields = `
+starredRepositories(first: 100, after: $cursor, orderBy: {field: STARRED_AT, direction: DESC}) {
+  edges {
+    starredAt
+    node {
+      databaseId
+      nameWithOwner
+      url
+      description
+      homepageUrl
+      isArchived
+      isDisabled
+      isFork
+      stargazerCount
+      forkCount
+      pushedAt
+      updatedAt
+      defaultBranchRef { name }
+      primaryLanguage { name }
+      licenseInfo { spdxId }
+      parent { nameWithOwner }
+      repositoryTopics(first: 20) { nodes { topic { name } } }
+    }
+  }
+  pageInfo { hasNextPage endCursor }
+}`
+
+const viewerStarredQuery = `query($cursor: String) { viewer {` + starredFields + `} }`
+
+const userStarredQuery = `query($login: String!, $cursor: String) { user(login: $login) {` + starredFields + `} }`
+
+// starredPage is a page of starred repositories.
+type starredPage struct {
+	Edges []struct {
+		StarredAt time.Time `json:"starredAt"`
+		Node      struct {
+			DatabaseID       int64     `json:"databaseId"`
+			NameWithOwner    string    `json:"nameWithOwner"`
+			URL              string    `json:"url"`
+			Description      string    `json:"description"`
+			HomepageURL      string    `json:"homepageUrl"`
+			IsArchived       bool      `json:"isArchived"`
+			IsDisabled       bool      `json:"isDisabled"`
+			IsFork           bool      `json:"isFork"`
+			StargazerCount   int       `json:"stargazerCount"`
+			ForkCount        int       `json:"forkCount"`
+			PushedAt         time.Time `json:"pushedAt"`
+			UpdatedAt        time.Time `json:"updatedAt"`
+			DefaultBranchRef *struct {
+				Name string `json:"name"`
+			} `json:"defaultBranchRef"`
+			PrimaryLanguage *struct {
+				Name string `json:"name"`
+			} `json:"primaryLanguage"`
+			LicenseInfo *struct {
+				SpdxID string `json:"spdxId"`
+			} `json:"licenseInfo"`
+			Parent *struct {
+				NameWithOwner string `json:"nameWithOwner"`
+			} `json:"parent"`
+			RepositoryTopics struct {
+				Nodes []struct {
+					Topic struct {
+						Name string `json:"name"`
+					} `json:"topic"`
+				} `json:"nodes"`
+			} `json:"repositoryTopics"`
+		} `json:"node"`
+	} `json:"edges"`
+	PageInfo pageInfo `json:"pageInfo"`
+}
+
+// GetStarredRepos fetches all starred repositories for the authenticated user,
+// or for User if it is set.
+func (c *GraphQLClient) GetStarredRepos(ctx context.Context) ([]StarredRepo, error) {
+	return c.GetStarredReposSince(ctx, time.Time{})
+}
+
+// GetStarredReposSince fetches the repositories starred at or after since,
+// newest first, stopping as soon as an older star is seen. A zero since
+// fetches everything.
+func (c *GraphQLClient) GetStarredReposSince(ctx context.Context, since time.Time) ([]StarredRepo, error) {
+	allRepos := []StarredRepo{}
+	cursor := ""
+
+	for {
+		page, err := c.starredPage(ctx, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch starred repositories: %w", err)
+		}
+
+		for _, edge := range page.Edges {
+			// Everything from here on was starred before the cut-off
+			if !since.IsZero() && edge.StarredAt.Before(since) {
+				return allRepos, nil
+			}
+
+			node := edge.Node
+			repo := StarredRepo{
+				ID:          node.DatabaseID,
+				FullName:    node.NameWithOwner,
+				HTMLURL:     node.URL,
+				Description: node.Description,
+				Topics:      make([]string, 0, len(node.RepositoryTopics.Nodes)),
+				StarredAt:   edge.StarredAt,
+				Stargazers:  node.StargazerCount,
+				Forks:       node.ForkCount,
+				Archived:    node.IsArchived,
+				Disabled:    node.IsDisabled,
+				Fork:        node.IsFork,
+				Homepage:    node.HomepageURL,
+				PushedAt:    node.PushedAt,
+				UpdatedAt:   node.UpdatedAt,
+			}
+			for _, topic := range node.RepositoryTopics.Nodes {
+				repo.Topics = append(repo.Topics, topic.Topic.Name)
+			}
+			if node.PrimaryLanguage != nil {
+				repo.Language = node.PrimaryLanguage.Name
+			}
+			if node.DefaultBranchRef != nil {
+				repo.DefaultBranch = node.DefaultBranchRef.Name
+			}
+			if node.LicenseInfo != nil {
+				repo.License = node.LicenseInfo.SpdxID
+			}
+			if node.Parent != nil {
+				repo.Parent = node.Parent.NameWithOwner
+			}
+
+			allRepos = append(allRepos, repo)
+		}
+
+		if !page.PageInfo.HasNextPage {
+			return allRepos, nil
+		}
+		cursor = page.PageInfo.EndCursor
+	}
+}
+
+// starredPage fetches the page of starred repositories after cursor.
+func (c *GraphQLClient) starredPage(ctx context.Context, cursor string) (starredPage, error) {
+	var owner struct {
+		StarredRepositories starredPage `json:"starredRepositories"`
+	}
+	err := c.queryOwner(ctx, viewerStarredQuery, userStarredQuery, map[string]any{"cursor": nullable(cursor)}, &owner)
+	return owner.StarredRepositories, err
+}
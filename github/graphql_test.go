@@ -0,0 +1,185 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// starredPageJSON is a GraphQL response holding two starred repositories.
+const starredPageJSON = `{"data": {"viewer": {"starredRepositories": {
+	"edges": [
+		{
+			"starredAt": "2024-03-01T00:00:00Z",
+			"node": {
+				"databaseId": 654321,
+				"nameWithOwner": "owner/fork",
+				"url": "https://github.com/owner/fork",
+				"description": "A fork",
+				"homepageUrl": "https://fork.example.com",
+				"isArchived": true,
+				"isDisabled": false,
+				"isFork": true,
+				"stargazerCount": 42,
+				"forkCount": 7,
+				"pushedAt": "2024-05-01T12:00:00Z",
+				"updatedAt": "2024-05-02T08:00:00Z",
+				"defaultBranchRef": {"name": "main"},
+				"primaryLanguage": {"name": "Go"},
+				"licenseInfo": {"spdxId": "MIT"},
+				"parent": {"nameWithOwner": "upstream/fork"},
+				"repositoryTopics": {"nodes": [{"topic": {"name": "cli"}}, {"topic": {"name": "go"}}]}
+			}
+		},
+		{
+			"starredAt": "2023-06-01T00:00:00Z",
+			"node": {
+				"nameWithOwner": "owner/bare",
+				"url": "https://github.com/owner/bare",
+				"description": null,
+				"homepageUrl": null,
+				"isArchived": false,
+				"isDisabled": false,
+				"isFork": false,
+				"stargazerCount": 0,
+				"forkCount": 0,
+				"pushedAt": null,
+				"updatedAt": "2023-01-01T00:00:00Z",
+				"defaultBranchRef": null,
+				"primaryLanguage": null,
+				"licenseInfo": null,
+				"parent": null,
+				"repositoryTopics": {"nodes": []}
+			}
+		}
+	],
+	"pageInfo": {"hasNextPage": %s, "endCursor": "c1"}
+}}}}`
+
+// TestGraphQLClient_GetStarredRepos tests fetching and converting starred
+// repositories, following pages.
+func TestGraphQLClient_GetStarredRepos(t *testing.T) {
+	var cursors []any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		cursors = append(cursors, req.Variables["cursor"])
+
+		hasNext := "true"
+		if len(cursors) > 1 {
+			hasNext = "false"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(strings.Replace(starredPageJSON, "%s", hasNext, 1)))
+	}))
+	defer server.Close()
+
+	client := NewGraphQLClient("test-token")
+	client.baseURL = server.URL
+
+	repos, err := client.GetStarredRepos(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(cursors) != 2 || cursors[0] != nil || cursors[1] != "c1" {
+		t.Errorf("expected cursors [nil c1], got %v", cursors)
+	}
+	if len(repos) != 4 {
+		t.Fatalf("expected 4 repos, got %d", len(repos))
+	}
+
+	fork := repos[0]
+	if fork.ID != 654321 || fork.FullName != "owner/fork" || fork.HTMLURL != "https://github.com/owner/fork" || fork.Description != "A fork" {
+		t.Errorf("unexpected basic fields %+v", fork)
+	}
+	if !fork.StarredAt.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected StarredAt %v", fork.StarredAt)
+	}
+	if strings.Join(fork.Topics, ",") != "cli,go" {
+		t.Errorf("expected topics [cli go], got %v", fork.Topics)
+	}
+	if fork.Language != "Go" || fork.Stargazers != 42 || !fork.Archived {
+		t.Errorf("unexpected language, stargazers or archived %+v", fork)
+	}
+	if fork.Forks != 7 || !fork.Fork || fork.Disabled || fork.DefaultBranch != "main" {
+		t.Errorf("unexpected forks, fork flag or default branch %+v", fork)
+	}
+	if !fork.PushedAt.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected PushedAt %v", fork.PushedAt)
+	}
+	if fork.Parent != "upstream/fork" || fork.Homepage != "https://fork.example.com" || fork.License != "MIT" {
+		t.Errorf("unexpected parent, homepage or licence %+v", fork)
+	}
+
+	bare := repos[1]
+	if bare.Topics == nil || len(bare.Topics) != 0 {
+		t.Errorf("expected empty non-nil topics, got %v", bare.Topics)
+	}
+	if bare.Language != "" || bare.License != "" || bare.Parent != "" || bare.Homepage != "" {
+		t.Errorf("expected empty optional fields, got %+v", bare)
+	}
+}
+
+// TestGraphQLClient_GetStarredReposSince tests that paging stops at older stars.
+func TestGraphQLClient_GetStarredReposSince(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(strings.Replace(starredPageJSON, "%s", "true", 1)))
+	}))
+	defer server.Close()
+
+	client := NewGraphQLClient("test-token")
+	client.baseURL = server.URL
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	repos, err := client.GetStarredReposSince(context.Background(), since)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+	if len(repos) != 1 || repos[0].FullName != "owner/fork" {
+		t.Errorf("expected only owner/fork, got %v", repos)
+	}
+}
+
+// TestGraphQLClient_User tests fetching another user's stars.
+func TestGraphQLClient_User(t *testing.T) {
+	var req graphQLRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"user": {"starredRepositories": {"edges": [], "pageInfo": {"hasNextPage": false}}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewGraphQLClient("test-token")
+	client.baseURL = server.URL
+	client.User = "octocat"
+
+	repos, err := client.GetStarredRepos(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if req.Variables["login"] != "octocat" {
+		t.Errorf("expected login octocat, got %v", req.Variables["login"])
+	}
+	if repos == nil || len(repos) != 0 {
+		t.Errorf("expected empty non-nil slice, got %v", repos)
+	}
+}
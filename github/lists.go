@@ -0,0 +1,146 @@
+package github
+
+import (
+	"context"
+	"fmt"
+)
+
+// StarList is one of a user's star lists, and the repositories in it.
+type StarList struct {
+	Name string
+	Slug string
+	// Repos holds the full names of the repositories in the list.
+	Repos []string
+}
+
+// listFields selects a page of star lists, each with its first page of
+// repositories.
+const listFields = `
+lists(first: 100, after: $cursor) {
+  nodes {
+    id
+    name
+    slug
+    items(first: 100) {
+      nodes { ... on Repository { nameWithOwner } }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+  pageInfo { hasNextPage endCursor }
+}`
+
+const viewerListsQuery = `query($cursor: String) { viewer {` + listFields + `} }`
+
+const userListsQuery = `query($login: String!, $cursor: String) { user(login: $login) {` + listFields + `} }`
+
+// listItemsQuery fetches a further page of repositories in a single list.
+const listItemsQuery = `
+query($id: ID!, $cursor: String) {
+  node(id: $id) {
+    ... on UserList {
+      items(first: 100, after: $cursor) {
+        nodes { ... on Repository { nameWithOwner } }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}`
+
+// listItems is a page of repositories in a star list. Lists may one day hold
+// things other than repositories; those have no name and are ignored.
+type listItems struct {
+	Nodes []struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"nodes"`
+	PageInfo pageInfo `json:"pageInfo"`
+}
+
+// listsPage is a page of star lists.
+type listsPage struct {
+	Nodes []struct {
+		ID    string    `json:"id"`
+		Name  string    `json:"name"`
+		Slug  string    `json:"slug"`
+		Items listItems `json:"items"`
+	} `json:"nodes"`
+	PageInfo pageInfo `json:"pageInfo"`
+}
+
+// GetStarLists fetches the authenticated user's star lists, or User's public
+// star lists if it is set, through the GraphQL API. This needs a token even
+// when User is set.
+func (c *Client) GetStarLists(ctx context.Context) ([]StarList, error) {
+	var lists []StarList
+	cursor := ""
+
+	for {
+		page, err := c.listsPage(ctx, cursor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch star lists: %w", err)
+		}
+
+		for _, node := range page.Nodes {
+			list := StarList{Name: node.Name, Slug: node.Slug, Repos: []string{}}
+			items := node.Items
+
+			for {
+				for _, item := range items.Nodes {
+					if item.NameWithOwner != "" {
+						list.Repos = append(list.Repos, item.NameWithOwner)
+					}
+				}
+				if !items.PageInfo.HasNextPage {
+					break
+				}
+
+				items, err = c.listItemsPage(ctx, node.ID, items.PageInfo.EndCursor)
+				if err != nil {
+					return nil, fmt.Errorf("failed to fetch star list %q: %w", node.Name, err)
+				}
+			}
+
+			lists = append(lists, list)
+		}
+
+		if !page.PageInfo.HasNextPage {
+			break
+		}
+		cursor = page.PageInfo.EndCursor
+	}
+
+	// Ensure we return an empty slice rather than nil
+	if lists == nil {
+		lists = []StarList{}
+	}
+
+	return lists, nil
+}
+
+// listsPage fetches the page of star lists after cursor.
+func (c *Client) listsPage(ctx context.Context, cursor string) (listsPage, error) {
+	var owner struct {
+		Lists listsPage `json:"lists"`
+	}
+	err := c.queryOwner(ctx, viewerListsQuery, userListsQuery, map[string]any{"cursor": nullable(cursor)}, &owner)
+	return owner.Lists, err
+}
+
+// listItemsPage fetches the page of repositories after cursor in the list
+// with the given node ID.
+func (c *Client) listItemsPage(ctx context.Context, id, cursor string) (listItems, error) {
+	var data struct {
+		Node struct {
+			Items listItems `json:"items"`
+		} `json:"node"`
+	}
+	err := c.graphQL(ctx, listItemsQuery, map[string]any{"id": id, "cursor": nullable(cursor)}, &data)
+	return data.Node.Items, err
+}
+
+// nullable returns nil for an empty string, so it is sent as a GraphQL null.
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,133 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestGetStarLists tests fetching lists, following pages of list items.
+func TestGetStarLists(t *testing.T) {
+	var requests []graphQLRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/graphql" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(req.Query, "node(id:") {
+			w.Write([]byte(`{"data": {"node": {"items": {
+				"nodes": [{"nameWithOwner": "owner/three"}],
+				"pageInfo": {"hasNextPage": false, "endCursor": "c2"}
+			}}}}`))
+			return
+		}
+
+		w.Write([]byte(`{"data": {"viewer": {"lists": {
+			"nodes": [
+				{
+					"id": "L1", "name": "Dev Tools", "slug": "dev-tools",
+					"items": {
+						"nodes": [{"nameWithOwner": "owner/one"}, {}, {"nameWithOwner": "owner/two"}],
+						"pageInfo": {"hasNextPage": true, "endCursor": "c1"}
+					}
+				},
+				{
+					"id": "L2", "name": "Empty", "slug": "empty",
+					"items": {"nodes": [], "pageInfo": {"hasNextPage": false}}
+				}
+			],
+			"pageInfo": {"hasNextPage": false}
+		}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	lists, err := client.GetStarLists(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	if requests[1].Variables["id"] != "L1" || requests[1].Variables["cursor"] != "c1" {
+		t.Errorf("unexpected items page variables %v", requests[1].Variables)
+	}
+
+	if len(lists) != 2 {
+		t.Fatalf("expected 2 lists, got %d", len(lists))
+	}
+	if lists[0].Name != "Dev Tools" || lists[0].Slug != "dev-tools" {
+		t.Errorf("unexpected list %+v", lists[0])
+	}
+	expected := []string{"owner/one", "owner/two", "owner/three"}
+	if strings.Join(lists[0].Repos, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected repos %v, got %v", expected, lists[0].Repos)
+	}
+	if lists[1].Repos == nil || len(lists[1].Repos) != 0 {
+		t.Errorf("expected empty non-nil repos, got %v", lists[1].Repos)
+	}
+}
+
+// TestGetStarLists_User tests fetching another user's lists.
+func TestGetStarLists_User(t *testing.T) {
+	var req graphQLRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"user": null}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+	client.User = "nobody"
+
+	if _, err := client.GetStarLists(context.Background()); err == nil {
+		t.Fatal("expected error for unknown user, got nil")
+	}
+	if req.Variables["login"] != "nobody" || !strings.Contains(req.Query, "user(login: $login)") {
+		t.Errorf("expected a user query for nobody, got %+v", req)
+	}
+}
+
+// TestGetStarLists_Errors tests that GraphQL errors and a missing token are reported.
+func TestGetStarLists_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": null, "errors": [{"message": "Something went wrong"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+
+	_, err := client.GetStarLists(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Something went wrong") {
+		t.Errorf("expected GraphQL error message, got %v", err)
+	}
+
+	client = NewClient("")
+	client.baseURL = server.URL
+
+	if _, err := client.GetStarLists(context.Background()); err == nil {
+		t.Error("expected error without a token, got nil")
+	}
+}
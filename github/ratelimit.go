@@ -0,0 +1,128 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secondaryRateLimitWait is how long to wait after hitting a secondary rate
+// limit that doesn't say when to retry, as GitHub recommends.
+const secondaryRateLimitWait = time.Minute
+
+// maxRateLimitAttempts bounds how many times a request is retried after
+// waiting for a rate limit to reset.
+const maxRateLimitAttempts = 3
+
+// RateLimitError is returned when GitHub refuses a request because a rate
+// limit has been exceeded.
+type RateLimitError struct {
+	// Reset is when GitHub will accept requests again.
+	Reset time.Time
+	// Secondary is true for GitHub's secondary rate limits, which guard
+	// against bursts of requests rather than the hourly total.
+	Secondary bool
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "rate limit"
+	if e.Secondary {
+		kind = "secondary rate limit"
+	}
+	return fmt.Sprintf("GitHub %s exceeded, resets at %s", kind, e.Reset.Format(time.RFC3339))
+}
+
+// rateLimitError returns a RateLimitError if resp shows that a rate limit was
+// exceeded, or nil otherwise. It may read the response body.
+func (c *Client) rateLimitError(resp *http.Response) *RateLimitError {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	now := c.now()
+
+	// Retry-After is used for secondary rate limits, and takes priority.
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return &RateLimitError{Reset: now.Add(time.Duration(seconds) * time.Second), Secondary: true}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return &RateLimitError{Reset: time.Unix(reset, 0)}
+		}
+	}
+
+	// Other secondary rate limits can only be told apart from permission
+	// errors by their message.
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode == http.StatusTooManyRequests || strings.Contains(strings.ToLower(string(body)), "rate limit") {
+		return &RateLimitError{Reset: now.Add(secondaryRateLimitWait), Secondary: true}
+	}
+
+	return nil
+}
+
+// do sends the request built by newRequest. If a rate limit is exceeded, it
+// waits for the limit to reset and tries again, as allowed by
+// MaxRateLimitWait. newRequest is called for each attempt.
+func (c *Client) do(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+
+		limited := c.rateLimitError(resp)
+		if limited == nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if attempt == maxRateLimitAttempts {
+			return nil, limited
+		}
+		if err := c.waitForReset(ctx, limited); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitForReset waits until the rate limit in limited resets, calling
+// OnRateLimit first. It returns limited without waiting if the reset is
+// further away than MaxRateLimitWait, and ctx's error if ctx is done first.
+func (c *Client) waitForReset(ctx context.Context, limited *RateLimitError) error {
+	wait := limited.Reset.Sub(c.now())
+	if wait > c.MaxRateLimitWait {
+		return limited
+	}
+	// Allow for clock differences between us and GitHub.
+	wait = max(wait, 0) + time.Second
+
+	if c.OnRateLimit != nil {
+		c.OnRateLimit(limited)
+	}
+
+	return c.sleep(ctx, wait)
+}
+
+// sleep pauses for d, returning early with ctx's error if ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
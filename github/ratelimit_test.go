@@ -0,0 +1,264 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRateLimitError tests recognising rate-limited responses.
+func TestRateLimitError(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	reset := now.Add(30 * time.Minute)
+
+	tests := []struct {
+		name      string
+		status    int
+		headers   map[string]string
+		body      string
+		want      time.Time
+		secondary bool
+		limited   bool
+	}{
+		{
+			name:   "primary",
+			status: http.StatusForbidden,
+			headers: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+			},
+			want:    reset,
+			limited: true,
+		},
+		{
+			name:      "retry after",
+			status:    http.StatusTooManyRequests,
+			headers:   map[string]string{"Retry-After": "90"},
+			want:      now.Add(90 * time.Second),
+			secondary: true,
+			limited:   true,
+		},
+		{
+			name:      "secondary message",
+			status:    http.StatusForbidden,
+			body:      `{"message":"You have exceeded a secondary rate limit."}`,
+			want:      now.Add(secondaryRateLimitWait),
+			secondary: true,
+			limited:   true,
+		},
+		{
+			name:   "permission denied",
+			status: http.StatusForbidden,
+			headers: map[string]string{
+				"X-RateLimit-Remaining": "4999",
+			},
+			body: `{"message":"Resource not accessible"}`,
+		},
+		{
+			name:   "not found",
+			status: http.StatusNotFound,
+		},
+	}
+
+	client := NewClient("")
+	client.now = func() time.Time { return now }
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			for key, value := range tt.headers {
+				resp.Header.Set(key, value)
+			}
+
+			got := client.rateLimitError(resp)
+			if !tt.limited {
+				if got != nil {
+					t.Errorf("expected no rate limit, got %v", got)
+				}
+				return
+			}
+
+			if got == nil {
+				t.Fatal("expected a rate limit")
+			}
+			if !got.Reset.Equal(tt.want) {
+				t.Errorf("expected reset %v, got %v", tt.want, got.Reset)
+			}
+			if got.Secondary != tt.secondary {
+				t.Errorf("expected secondary %v, got %v", tt.secondary, got.Secondary)
+			}
+		})
+	}
+}
+
+// newRateLimitedServer returns a server that rejects the first request with
+// a primary rate limit resetting a minute after now, then serves body, or no
+// content if body is empty.
+func newRateLimitedServer(t *testing.T, now time.Time, body string) (*httptest.Server, *int) {
+	t.Helper()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Minute).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+			return
+		}
+		if body == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+// TestGetStarredRepos_RateLimitWait tests waiting for a rate limit to reset
+// and trying again.
+func TestGetStarredRepos_RateLimitWait(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	server, requests := newRateLimitedServer(t, now, `[]`)
+
+	var slept time.Duration
+	var notified *RateLimitError
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+	client.now = func() time.Time { return now }
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		slept += d
+		return nil
+	}
+	client.MaxRateLimitWait = time.Hour
+	client.OnRateLimit = func(err *RateLimitError) { notified = err }
+
+	if _, err := client.GetStarredRepos(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if *requests != 2 {
+		t.Errorf("expected 2 requests, got %d", *requests)
+	}
+	if slept != time.Minute+time.Second {
+		t.Errorf("expected to sleep %v, got %v", time.Minute+time.Second, slept)
+	}
+	if notified == nil || !notified.Reset.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected OnRateLimit with the reset time, got %v", notified)
+	}
+}
+
+// TestGetStarredRepos_RateLimitError tests that a reset beyond
+// MaxRateLimitWait fails with a RateLimitError without waiting.
+func TestGetStarredRepos_RateLimitError(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	server, requests := newRateLimitedServer(t, now, `[]`)
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+	client.now = func() time.Time { return now }
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		t.Error("expected no wait")
+		return nil
+	}
+	client.MaxRateLimitWait = 30 * time.Second
+
+	_, err := client.GetStarredRepos(context.Background())
+
+	var limited *RateLimitError
+	if !errors.As(err, &limited) {
+		t.Fatalf("expected a RateLimitError, got %v", err)
+	}
+	if !limited.Reset.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected reset %v, got %v", now.Add(time.Minute), limited.Reset)
+	}
+	if *requests != 1 {
+		t.Errorf("expected 1 request, got %d", *requests)
+	}
+}
+
+// TestStarRepo_RateLimitWait tests that starring waits for a rate limit too.
+func TestStarRepo_RateLimitWait(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	server, requests := newRateLimitedServer(t, now, ``)
+
+	client := NewClient("test-token")
+	client.baseURL = server.URL
+	client.now = func() time.Time { return now }
+	client.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	client.MaxRateLimitWait = time.Hour
+
+	if err := client.StarRepo(context.Background(), "owner", "repo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *requests != 2 {
+		t.Errorf("expected 2 requests, got %d", *requests)
+	}
+}
+
+// TestGraphQLClient_RateLimited tests waiting when the GraphQL API reports a
+// rate limit in a successful response.
+func TestGraphQLClient_RateLimited(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		if requests == 1 {
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Minute).Unix(), 10))
+			w.Write([]byte(`{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`))
+			return
+		}
+		w.Write([]byte(strings.Replace(starredPageJSON, "%s", "false", 1)))
+	}))
+	defer server.Close()
+
+	var slept time.Duration
+
+	client := NewGraphQLClient("test-token")
+	client.baseURL = server.URL
+	client.now = func() time.Time { return now }
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		slept += d
+		return nil
+	}
+	client.MaxRateLimitWait = time.Hour
+
+	repos, err := client.GetStarredRepos(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(repos) != 2 {
+		t.Errorf("expected 2 repos, got %d", len(repos))
+	}
+	if slept != time.Minute+time.Second {
+		t.Errorf("expected to sleep %v, got %v", time.Minute+time.Second, slept)
+	}
+}
+
+// TestSleepCancelled tests that waiting stops when the context is done.
+func TestSleepCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -2,52 +2,255 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/monooso/gitboard/config"
 	"github.com/monooso/gitboard/export"
 	"github.com/monooso/gitboard/github"
 	"github.com/monooso/gitboard/pinboard"
+	"github.com/monooso/gitboard/state"
 )
 
 func main() {
+	configFile := flag.String("config", "", "Path to the config file (default $XDG_CONFIG_HOME/gitboard/config.json)")
+	profile := flag.String("profile", "", "Config file profile to use (overrides GITBOARD_PROFILE)")
 	githubToken := flag.String("github-token", "", "GitHub personal access token (overrides GITHUB_TOKEN)")
+	githubUser := flag.String("github-user", "", "Export this GitHub user's public stars instead of your own (no token needed)")
+	githubAPI := flag.String("github-api", "", "How to fetch stars: rest, or graphql for richer metadata (default rest)")
 	pinboardToken := flag.String("pinboard-token", "", "Pinboard API token (overrides PINBOARD_TOKEN)")
 	dryRun := flag.Bool("dry-run", false, "Print what would be exported without creating bookmarks")
+	update := flag.Bool("update", false, "Rewrite existing bookmarks whose repo metadata has changed")
+	prune := flag.String("prune", "", "Handle bookmarks for unstarred repos: report, delete, or retag")
+	merge := flag.String("merge", "", "How to rewrite existing bookmarks: overwrite, merge, or never")
+	order := flag.String("order", "", "Order to add bookmarks in: newest or oldest first (default newest)")
+	urlStrategy := flag.String("url-strategy", "", "URL to bookmark: repo, homepage, or repo-with-homepage (default repo)")
+	migrateURLs := flag.Bool("migrate-urls", false, "Move bookmarks made with another --url-strategy to the chosen URL")
+	privacy := flag.String("privacy", "", "Whether new bookmarks are private or public (default private)")
+	tagRulesFile := flag.String("tag-rules", "", "Path to a JSON file of tag mapping rules")
+	titleTemplate := flag.String("title-template", "", "Go template for bookmark titles (default {{.FullName}})")
+	descriptionTemplate := flag.String("description-template", "", "Go template for bookmark descriptions (default {{.Description}})")
+	includeOwner := flag.String("include-owner", "", "Only export repos owned by these users or orgs (comma-separated)")
+	excludeOwner := flag.String("exclude-owner", "", "Skip repos owned by these users or orgs (comma-separated)")
+	includeTopic := flag.String("include-topic", "", "Only export repos with at least one of these topics (comma-separated)")
+	excludeTopic := flag.String("exclude-topic", "", "Skip repos with any of these topics (comma-separated)")
+	starLists := flag.Bool("star-lists", false, "Tag bookmarks with the GitHub star lists their repo is in (needs a GitHub token)")
+	includeList := flag.String("include-list", "", "Only export repos in at least one of these star lists (comma-separated)")
+	excludeList := flag.String("exclude-list", "", "Skip repos in any of these star lists (comma-separated)")
+	includeLanguage := flag.String("include-language", "", "Only export repos whose primary language is one of these (comma-separated)")
+	excludeLanguage := flag.String("exclude-language", "", "Skip repos whose primary language is one of these (comma-separated)")
+	excludeArchived := flag.Bool("exclude-archived", false, "Skip archived repos")
+	excludeForks := flag.Bool("exclude-forks", false, "Skip forks")
+	minStars := flag.Int("min-stars", 0, "Skip repos with fewer stargazers than this")
+	includeName := flag.String("include-name", "", "Only export repos whose full name matches this regular expression")
+	excludeName := flag.String("exclude-name", "", "Skip repos whose full name matches this regular expression")
+	starredAfter := flag.String("starred-after", "", "Only export repos starred after this date (YYYY-MM-DD or RFC 3339)")
+	starredBefore := flag.String("starred-before", "", "Only export repos starred before this date (YYYY-MM-DD or RFC 3339)")
+	cacheDir := flag.String("cache-dir", "", "Directory for cached GitHub and Pinboard responses (default $XDG_CACHE_HOME/gitboard)")
+	cacheSize := flag.Int64("cache-size", github.DefaultCacheSize>>20, "Maximum size of the GitHub response cache, in MB (0 for no limit)")
+	noCache := flag.Bool("no-cache", false, "Don't cache GitHub and Pinboard responses")
+	clearCache := flag.Bool("clear-cache", false, "Delete cached GitHub and Pinboard responses and exit")
+	pinboardAttempts := flag.Int("pinboard-max-attempts", pinboard.DefaultMaxAttempts, "How many times to try a Pinboard request that fails with a rate limit, server or network error")
+	pinboardBackoff := flag.Duration("pinboard-max-backoff", pinboard.DefaultMaxBackoff, "Longest delay between attempts at a Pinboard request")
+	pinboardPageSize := flag.Int("pinboard-page-size", 0, "Fetch existing Pinboard bookmarks this many at a time, waiting 5 minutes between pages (0 to fetch them all at once)")
+	rateLimitWait := flag.Duration("rate-limit-wait", time.Hour, "Longest to wait for a GitHub rate limit to reset before giving up (0 to never wait)")
+	stateFile := flag.String("state-file", "", "Path to the local state file (default $XDG_STATE_HOME/gitboard/state.json)")
+	full := flag.Bool("full", false, "Fetch all stars instead of running incrementally")
+	resume := flag.Bool("resume", false, "Continue the last export that failed part way through")
+	star := flag.Bool("star", false, "Star GitHub repos bookmarked in Pinboard with --star-tag, instead of exporting")
+	starTag := flag.String("star-tag", export.DefaultStarTag, "Pinboard tag marking bookmarks to star")
 	flag.Parse()
 
-	if *githubToken == "" {
-		*githubToken = os.Getenv("GITHUB_TOKEN")
+	cache, pbCache, err := openCaches(*cacheDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cache.MaxSize = *cacheSize << 20
+
+	if *clearCache {
+		if err := cache.Clear(); err != nil {
+			log.Fatal(err)
+		}
+		if err := pbCache.Clear(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Cache cleared")
+		return
+	}
+
+	prunePolicy, err := export.ParsePrunePolicy(*prune)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mergeStrategy, err := export.ParseMergeStrategy(*merge)
+	if err != nil {
+		log.Fatal(err)
+	}
+	urlChoice, err := export.ParseURLStrategy(*urlStrategy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	exportOrder, err := export.ParseOrder(*order)
+	if err != nil {
+		log.Fatal(err)
 	}
-	if *pinboardToken == "" {
-		*pinboardToken = os.Getenv("PINBOARD_TOKEN")
+
+	// Settings given on the command line take precedence over the config file.
+	cli := config.Profile{
+		GitHubToken:         *githubToken,
+		PinboardToken:       *pinboardToken,
+		GitHubUser:          *githubUser,
+		GitHubAPI:           *githubAPI,
+		TitleTemplate:       *titleTemplate,
+		DescriptionTemplate: *descriptionTemplate,
+		Privacy:             *privacy,
+		Filter: config.Filter{
+			IncludeOwners: splitList(*includeOwner),
+			ExcludeOwners: splitList(*excludeOwner),
+			IncludeTopics: splitList(*includeTopic),
+			ExcludeTopics: splitList(*excludeTopic),
+			IncludeLists:  splitList(*includeList),
+			ExcludeLists:  splitList(*excludeList),
+
+			IncludeLanguages: splitList(*includeLanguage),
+			ExcludeLanguages: splitList(*excludeLanguage),
+
+			IncludeName:   *includeName,
+			ExcludeName:   *excludeName,
+			StarredAfter:  *starredAfter,
+			StarredBefore: *starredBefore,
+		},
+	}
+	// Only flags given explicitly can switch off a config file setting.
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "star-lists":
+			cli.StarLists = starLists
+		case "exclude-archived":
+			cli.Filter.ExcludeArchived = excludeArchived
+		case "exclude-forks":
+			cli.Filter.ExcludeForks = excludeForks
+		case "min-stars":
+			cli.Filter.MinStars = minStars
+		}
+	})
+	if *tagRulesFile != "" {
+		rules, err := export.LoadTagRules(*tagRulesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cli.TagRules = &rules
 	}
 
-	if *githubToken == "" {
-		log.Fatal("GitHub token is required: set GITHUB_TOKEN or use --github-token")
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		log.Fatal(err)
 	}
-	if *pinboardToken == "" {
-		log.Fatal("Pinboard token is required: set PINBOARD_TOKEN or use --pinboard-token")
+	settings, err := config.Resolve(cfg, *profile, cli, os.Getenv)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	gh := github.NewClient(*githubToken)
-	pb := pinboard.NewClient(*pinboardToken)
+	// Public stars can be read through REST without a token, but starring,
+	// GraphQL and star lists need one.
+	if settings.GitHubToken == "" && (settings.GitHubUser == "" || *star || settings.GraphQL || settings.StarLists) {
+		log.Fatal("GitHub token is required: set GITHUB_TOKEN, use --github-token, or add it to the config file")
+	}
+	if settings.PinboardToken == "" {
+		log.Fatal("Pinboard token is required: set PINBOARD_TOKEN, use --pinboard-token, or add it to the config file")
+	}
+	if err := export.CheckPrune(prunePolicy, settings.GitHubUser, settings.TagRules); err != nil {
+		log.Fatal(err)
+	}
+
+	ghClient := github.NewClient(settings.GitHubToken)
+	ghClient.User = settings.GitHubUser
+	if !*noCache {
+		ghClient.Cache = cache
+	}
+	ghClient.MaxRateLimitWait = *rateLimitWait
+	ghClient.OnRateLimit = func(err *github.RateLimitError) {
+		fmt.Fprintf(os.Stderr, "\r%s\rGitHub rate limit reached, waiting until %s\n", strings.Repeat(" ", 80), err.Reset.Local().Format(time.TimeOnly))
+	}
+	var gh export.GitHubClient = ghClient
+	if settings.GraphQL {
+		gh = &github.GraphQLClient{Client: ghClient}
+	}
+	pb := pinboard.NewClient(settings.PinboardToken)
+	if !*noCache {
+		pb.Cache = pbCache
+	}
+	pb.PageSize = *pinboardPageSize
+	pb.MaxAttempts = *pinboardAttempts
+	pb.MaxBackoff = *pinboardBackoff
+
+	if *star {
+		runStar(context.Background(), pb, ghClient, *starTag, *dryRun)
+		return
+	}
+
+	stateDir, err := state.DefaultDir()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Keep each profile and user apart, so incremental runs for one don't
+	// skip stars for another.
+	stateDir = state.AccountDir(stateDir, settings.Profile, settings.GitHubUser)
+	if *stateFile == "" {
+		*stateFile = filepath.Join(stateDir, "state.json")
+	}
+	store, err := state.Load(*stateFile)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	exporter := export.NewExporter(gh, pb)
+	exporter.State = store
+	exporter.Incremental = !*full
+	exporter.CheckpointPath = filepath.Join(filepath.Dir(*stateFile), "checkpoint.json")
+	exporter.Resume = *resume
 	exporter.DryRun = *dryRun
+	exporter.Update = *update
+	exporter.Prune = prunePolicy
+	exporter.Merge = mergeStrategy
+	exporter.URLStrategy = urlChoice
+	exporter.Order = exportOrder
+	exporter.MigrateURLs = *migrateURLs
+	exporter.Privacy = settings.Privacy
+	exporter.TagRules = settings.TagRules
+	exporter.Filter = settings.Filter
+	exporter.StarLists = settings.StarLists
+	if settings.TitleTemplate != "" {
+		exporter.TitleTemplate, err = export.ParseTemplate("title", settings.TitleTemplate)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if settings.DescriptionTemplate != "" {
+		exporter.DescriptionTemplate, err = export.ParseTemplate("description", settings.DescriptionTemplate)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 	exporter.OnProgress = func(p export.Progress) {
-		action := "adding"
-		if p.Skipped {
-			action = "exists"
-		} else if *dryRun {
-			action = "would add"
+		name := p.RepoName
+		if name == "" {
+			name = p.URL
+		}
+
+		// Report altered tags on their own lines, above the progress bar.
+		for _, change := range p.TagChanges {
+			fmt.Fprintf(os.Stderr, "\r%s\r%s: %s\n", strings.Repeat(" ", 80), name, change)
 		}
 
 		bar := progressBar(p.Current, p.Total, 30)
-		fmt.Fprintf(os.Stderr, "\r%s %d/%d %s: %s", bar, p.Current, p.Total, action, p.RepoName)
+		fmt.Fprintf(os.Stderr, "\r%s %d/%d %s: %s", bar, p.Current, p.Total, actionLabel(p.Action, *dryRun), name)
 
 		// Pad with spaces to clear any leftover characters from longer previous lines.
 		fmt.Fprintf(os.Stderr, "    ")
@@ -59,15 +262,194 @@ func main() {
 	// Clear the progress line.
 	fmt.Fprintf(os.Stderr, "\r%s\r", strings.Repeat(" ", 80))
 
+	// Save progress even if the export failed part way through.
+	if !*dryRun {
+		if saveErr := store.Save(); saveErr != nil {
+			log.Printf("Failed to save state: %v", saveErr)
+		}
+	}
+
+	if errors.Is(err, export.ErrNoCheckpoint) {
+		log.Fatal("Nothing to resume: the last export completed or was never started")
+	}
 	if err != nil {
+		if !*dryRun {
+			log.Fatalf("Export failed: %v\nRun again with --resume to continue where it left off.", err)
+		}
 		log.Fatalf("Export failed: %v", err)
 	}
 
 	if *dryRun {
-		fmt.Printf("Dry run: %d new, %d existing, %d total\n", result.Added, result.Skipped, result.Total)
+		fmt.Printf("Dry run: %d new, %d changed, %d existing, %d filtered, %d total\n", result.Added, result.Updated, result.Skipped, result.Filtered, result.Total)
+	} else {
+		fmt.Printf("Done: %d added, %d updated, %d skipped, %d filtered, %d total\n", result.Added, result.Updated, result.Skipped, result.Filtered, result.Total)
+	}
+
+	if *migrateURLs {
+		fmt.Printf("Moved: %d bookmarks to the %s URL\n", result.Moved, urlLabel(urlChoice))
+	}
+
+	if result.Renamed > 0 {
+		fmt.Printf("Renamed: %d bookmarks moved to their repo's new URL\n", result.Renamed)
+	}
+
+	if len(result.Duplicates) > 0 {
+		fmt.Printf("Differently spelt: %d bookmarks match a starred repo under another spelling of its URL\n", len(result.Duplicates))
+		for _, url := range result.Duplicates {
+			fmt.Printf("  %s\n", url)
+		}
+	}
+
+	if prunePolicy != export.PruneOff {
+		if *dryRun {
+			fmt.Printf("Unstarred: %d found, %d would remove, %d would retag\n", len(result.Orphaned), result.Removed, result.Retagged)
+		} else {
+			fmt.Printf("Unstarred: %d found, %d removed, %d retagged\n", len(result.Orphaned), result.Removed, result.Retagged)
+		}
+		if prunePolicy == export.PruneReport {
+			for _, url := range result.Orphaned {
+				fmt.Printf("  %s\n", url)
+			}
+		}
+	}
+}
+
+// runStar stars the GitHub repos bookmarked in Pinboard with the given tag,
+// and prints a summary.
+func runStar(ctx context.Context, pb *pinboard.Client, gh *github.Client, tag string, dryRun bool) {
+	starrer := export.NewStarrer(pb, gh)
+	starrer.Tag = tag
+	starrer.DryRun = dryRun
+	starrer.OnProgress = func(p export.Progress) {
+		action := "not a repo"
+		if p.Action == export.ActionStar {
+			action = "starring"
+			if dryRun {
+				action = "would star"
+			}
+		}
+
+		bar := progressBar(p.Current, p.Total, 30)
+		fmt.Fprintf(os.Stderr, "\r%s %d/%d %s: %s    ", bar, p.Current, p.Total, action, p.URL)
+	}
+
+	result, err := starrer.Run(ctx)
+
+	// Clear the progress line.
+	fmt.Fprintf(os.Stderr, "\r%s\r", strings.Repeat(" ", 80))
+
+	if err != nil {
+		log.Fatalf("Starring failed: %v", err)
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d to star, %d skipped, %d total\n", result.Starred, result.Skipped, result.Total)
 	} else {
-		fmt.Printf("Done: %d added, %d skipped, %d total\n", result.Added, result.Skipped, result.Total)
+		fmt.Printf("Done: %d starred, %d skipped, %d total\n", result.Starred, result.Skipped, result.Total)
 	}
+	for _, repo := range result.Repos {
+		fmt.Printf("  %s\n", repo)
+	}
+}
+
+// actionLabel returns the progress label for an exporter action.
+func actionLabel(action export.Action, dryRun bool) string {
+	switch action {
+	case export.ActionSkip:
+		return "exists"
+	case export.ActionFilter:
+		return "filtered"
+	case export.ActionUpdate:
+		if dryRun {
+			return "would update"
+		}
+		return "updating"
+	case export.ActionReport:
+		return "unstarred"
+	case export.ActionDelete:
+		if dryRun {
+			return "would remove"
+		}
+		return "removing"
+	case export.ActionRetag:
+		if dryRun {
+			return "would retag"
+		}
+		return "retagging"
+	case export.ActionMove:
+		if dryRun {
+			return "would move"
+		}
+		return "moving"
+	case export.ActionRename:
+		if dryRun {
+			return "would follow rename"
+		}
+		return "following rename"
+	default:
+		if dryRun {
+			return "would add"
+		}
+		return "adding"
+	}
+}
+
+// urlLabel describes the URL chosen by a URL strategy.
+func urlLabel(strategy export.URLStrategy) string {
+	if strategy == export.URLHomepage {
+		return "homepage"
+	}
+	return "repo page"
+}
+
+// splitList splits a comma-separated flag value, ignoring empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadConfig loads the config file at path, or from the default location if
+// path is empty. A missing default config file is not an error.
+func loadConfig(path string) (*config.File, error) {
+	if path != "" {
+		return config.Load(path)
+	}
+
+	path, err := config.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := config.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return cfg, err
+}
+
+// openCaches returns the GitHub and Pinboard response caches, in the github
+// and pinboard subdirectories of dir, or in their default locations if dir is
+// empty.
+func openCaches(dir string) (*github.Cache, *pinboard.Cache, error) {
+	if dir != "" {
+		return github.NewCache(filepath.Join(dir, "github")), pinboard.NewCache(filepath.Join(dir, "pinboard")), nil
+	}
+
+	ghDir, err := github.DefaultCacheDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	pbDir, err := pinboard.DefaultCacheDir()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return github.NewCache(ghDir), pinboard.NewCache(pbDir), nil
 }
 
 // progressBar returns a simple text progress bar of the given width.
@@ -0,0 +1,200 @@
+package pinboard
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache stores the results of posts/all on disk, with the time of the last
+// change to the account when they were fetched. While posts/update reports
+// the same time, the stored result is still current, so Pinboard doesn't
+// have to send the whole list again.
+//
+// Entries are written and read a post at a time, so large accounts don't
+// have to fit in memory. Each is a JSON object holding update_time followed
+// by posts.
+type Cache struct {
+	dir string
+}
+
+// NewCache creates a cache in dir. The directory is created when the first
+// result is stored.
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultCacheDir returns the default cache directory, following the XDG base
+// directory specification: $XDG_CACHE_HOME/gitboard/pinboard, falling back to
+// ~/.cache/gitboard/pinboard.
+func DefaultCacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "gitboard", "pinboard"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".cache", "gitboard", "pinboard"), nil
+}
+
+// Clear removes every cached result.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+	return nil
+}
+
+// path returns the file holding the bookmarks with the given tag. Each
+// account has its own bookmarks, so the token is part of the key. It is
+// hashed, never stored.
+func (c *Cache) path(token, tag string) string {
+	sum := sha256.Sum256([]byte(token + "\n" + tag))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// walk calls fn with each cached post with the given tag, if they were
+// cached when the account was last updated at updated. It reports whether
+// they were. A missing, stale or unreadable entry is a miss, as long as that
+// is noticed before any post is passed to fn.
+func (c *Cache) walk(token, tag string, updated time.Time, fn func(post) error) (bool, error) {
+	f, err := os.Open(c.path(token, tag))
+	if err != nil {
+		return false, nil
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	if expectDelim(dec, '{') != nil || expectKey(dec, "update_time") != nil {
+		return false, nil
+	}
+
+	var cached time.Time
+	if err := dec.Decode(&cached); err != nil || !cached.Equal(updated) {
+		return false, nil
+	}
+
+	if expectKey(dec, "posts") != nil {
+		return false, nil
+	}
+
+	if _, err := decodePosts(dec, fn); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// expectKey reads the next JSON token and returns an error unless it is the
+// given object key.
+func expectKey(dec *json.Decoder, key string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != key {
+		return fmt.Errorf("expected key %q, got %v", key, tok)
+	}
+	return nil
+}
+
+// cacheWriter stores a cache entry a post at a time. The entry only replaces
+// the previous one when it is committed, so a crash or an error can't leave
+// a truncated entry behind.
+type cacheWriter struct {
+	path string
+	tmp  *os.File
+	buf  *bufio.Writer
+	err  error
+
+	count int
+}
+
+// create starts a new entry for the posts with the given tag, fetched when
+// the account was last updated at updated. Any failure is reported by
+// commit.
+func (c *Cache) create(token, tag string, updated time.Time) *cacheWriter {
+	w := &cacheWriter{path: c.path(token, tag)}
+
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		w.err = fmt.Errorf("failed to create cache directory: %w", err)
+		return w
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		w.err = fmt.Errorf("failed to write cache entry: %w", err)
+		return w
+	}
+	w.tmp = tmp
+	w.buf = bufio.NewWriter(tmp)
+
+	header, err := json.Marshal(updated)
+	if err != nil {
+		w.err = fmt.Errorf("failed to encode cache entry: %w", err)
+		return w
+	}
+	w.write(`{"update_time":` + string(header) + `,"posts":[`)
+
+	return w
+}
+
+// add appends a post to the entry.
+func (w *cacheWriter) add(p post) {
+	data, err := json.Marshal(p)
+	if err != nil && w.err == nil {
+		w.err = fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	if w.count > 0 {
+		w.write(",")
+	}
+	w.write(string(data))
+	w.count++
+}
+
+// write appends raw JSON to the entry, unless it has already failed.
+func (w *cacheWriter) write(s string) {
+	if w.err != nil {
+		return
+	}
+	if _, err := w.buf.WriteString(s); err != nil {
+		w.err = fmt.Errorf("failed to write cache entry: %w", err)
+	}
+}
+
+// commit finishes the entry and puts it in place of any previous one.
+func (w *cacheWriter) commit() error {
+	w.write("]}")
+	if w.err != nil {
+		return w.err
+	}
+
+	if err := w.buf.Flush(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := w.tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(w.tmp.Name(), w.path); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// abort discards the entry unless it has been committed.
+func (w *cacheWriter) abort() {
+	if w.tmp == nil {
+		return
+	}
+	w.tmp.Close()
+	os.Remove(w.tmp.Name())
+}
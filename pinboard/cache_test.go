@@ -0,0 +1,206 @@
+package pinboard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// cachePosts stores an entry holding posts, failing the test on error.
+func cachePosts(t *testing.T, cache *Cache, token, tag string, updated time.Time, posts ...post) {
+	t.Helper()
+
+	w := cache.create(token, tag, updated)
+	defer w.abort()
+	for _, p := range posts {
+		w.add(p)
+	}
+	if err := w.commit(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// cachedPosts returns the cached posts, and whether there were any.
+func cachedPosts(t *testing.T, cache *Cache, token, tag string, updated time.Time) ([]post, bool) {
+	t.Helper()
+
+	var posts []post
+	hit, err := cache.walk(token, tag, updated, func(p post) error {
+		posts = append(posts, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return posts, hit
+}
+
+// TestCacheWriteAndWalk verifies that entries round-trip and are keyed by
+// token, tag and update time.
+func TestCacheWriteAndWalk(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"))
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cachePosts(t, cache, "token-a", "github-repo", updated,
+		post{Href: "https://github.com/foo/bar", Tags: "github-repo"},
+		post{Href: "https://github.com/baz/qux", Tags: "github-repo go"},
+	)
+
+	posts, hit := cachedPosts(t, cache, "token-a", "github-repo", updated)
+	if !hit {
+		t.Fatal("expected a cached entry")
+	}
+	if len(posts) != 2 || posts[0].Href != "https://github.com/foo/bar" || posts[1].Tags != "github-repo go" {
+		t.Errorf("unexpected posts %+v", posts)
+	}
+
+	if _, hit := cachedPosts(t, cache, "token-b", "github-repo", updated); hit {
+		t.Error("expected no entry for a different token")
+	}
+	if _, hit := cachedPosts(t, cache, "token-a", "other", updated); hit {
+		t.Error("expected no entry for a different tag")
+	}
+	if _, hit := cachedPosts(t, cache, "token-a", "github-repo", updated.Add(time.Minute)); hit {
+		t.Error("expected no entry once the account has changed")
+	}
+}
+
+// TestCacheEmptyEntry verifies that an account with no matching bookmarks is
+// cached too.
+func TestCacheEmptyEntry(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cachePosts(t, cache, "", "github-repo", updated)
+
+	posts, hit := cachedPosts(t, cache, "", "github-repo", updated)
+	if !hit || len(posts) != 0 {
+		t.Errorf("expected an empty hit, got %v %v", hit, posts)
+	}
+}
+
+// TestCacheAbort verifies that an entry that isn't committed doesn't replace
+// the previous one.
+func TestCacheAbort(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cachePosts(t, cache, "", "github-repo", updated, post{Href: "https://github.com/foo/bar"})
+
+	w := cache.create("", "github-repo", updated.Add(time.Minute))
+	w.add(post{Href: "https://github.com/baz/qux"})
+	w.abort()
+
+	posts, hit := cachedPosts(t, cache, "", "github-repo", updated)
+	if !hit || len(posts) != 1 || posts[0].Href != "https://github.com/foo/bar" {
+		t.Errorf("expected the previous entry, got %v %v", hit, posts)
+	}
+}
+
+// TestCacheClear verifies that clearing removes every entry.
+func TestCacheClear(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"))
+	updated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cachePosts(t, cache, "", "github-repo", updated)
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, hit := cachedPosts(t, cache, "", "github-repo", updated); hit {
+		t.Error("expected no entries after clearing")
+	}
+}
+
+// TestDefaultCacheDirUsesXDGCacheHome verifies that XDG_CACHE_HOME is
+// respected.
+func TestDefaultCacheDirUsesXDGCacheHome(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := filepath.Join("/tmp/xdg-cache", "gitboard", "pinboard"); dir != expected {
+		t.Errorf("expected %q, got %q", expected, dir)
+	}
+}
+
+// TestLastUpdate verifies that posts/update is parsed.
+func TestLastUpdate(t *testing.T) {
+	var receivedPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"update_time": "2024-03-01T12:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	updated, err := client.LastUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if receivedPath != "/posts/update" {
+		t.Errorf("expected path /posts/update, got %q", receivedPath)
+	}
+	if expected := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC); !updated.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, updated)
+	}
+}
+
+// TestGetBookmarksByTagCached verifies that posts/all is only called again
+// once posts/update shows a change.
+func TestGetBookmarksByTagCached(t *testing.T) {
+	updateTime := "2024-03-01T12:00:00Z"
+	allCalls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/posts/update":
+			w.Write([]byte(`{"update_time": "` + updateTime + `"}`))
+		case "/posts/all":
+			allCalls++
+			w.Write([]byte(`[{"href": "https://github.com/foo/bar", "description": "foo/bar", "tags": "github-repo", "shared": "no", "toread": "no"}]`))
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+	client.minDelay = 0
+	client.Cache = NewCache(t.TempDir())
+
+	for i := range 2 {
+		urls, err := client.GetBookmarkURLsByTag(context.Background(), "github-repo")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !urls["https://github.com/foo/bar"] {
+			t.Errorf("call %d: expected the cached bookmark, got %v", i+1, urls)
+		}
+	}
+	if allCalls != 1 {
+		t.Errorf("expected 1 call to posts/all while unchanged, got %d", allCalls)
+	}
+
+	updateTime = "2024-03-02T12:00:00Z"
+	bookmarks, err := client.GetBookmarksByTag(context.Background(), "github-repo")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if allCalls != 2 {
+		t.Errorf("expected posts/all to be called again after a change, got %d calls", allCalls)
+	}
+	if len(bookmarks) != 1 || bookmarks[0].Title != "foo/bar" || !bookmarks[0].Private {
+		t.Errorf("unexpected bookmarks %+v", bookmarks)
+	}
+}
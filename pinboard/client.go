@@ -3,10 +3,13 @@ package pinboard
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -19,8 +22,61 @@ type Bookmark struct {
 	Tags        []string
 	Private     bool
 	ToRead      bool
+
+	// Time is when the bookmark was created. If it is zero, AddBookmark
+	// lets Pinboard use the current time.
+	Time time.Time
+
+	// Hash is Pinboard's digest of the URL, and Meta a digest of the rest of
+	// the bookmark that changes whenever it is edited. They are filled in
+	// when reading bookmarks and ignored by AddBookmark.
+	Hash string
+	Meta string
 }
 
+// ErrNotFound is returned when a requested bookmark does not exist.
+var ErrNotFound = errors.New("bookmark not found")
+
+// post represents a single bookmark as returned by the v1 API.
+type post struct {
+	Href        string    `json:"href"`
+	Description string    `json:"description"`
+	Extended    string    `json:"extended"`
+	Tags        string    `json:"tags"`
+	Shared      string    `json:"shared"`
+	ToRead      string    `json:"toread"`
+	Time        time.Time `json:"time"`
+	Hash        string    `json:"hash"`
+	Meta        string    `json:"meta"`
+}
+
+// bookmark converts an API post to our domain model, reversing the v1 field
+// mapping used by AddBookmark.
+func (p post) bookmark() Bookmark {
+	return Bookmark{
+		URL:         p.Href,
+		Title:       p.Description,
+		Description: p.Extended,
+		Tags:        strings.Fields(p.Tags),
+		Private:     p.Shared == "no",
+		ToRead:      p.ToRead == "yes",
+		Time:        p.Time,
+		Hash:        p.Hash,
+		Meta:        p.Meta,
+	}
+}
+
+// DefaultMaxAttempts is how many times a request is tried by default before
+// giving up on a transient failure.
+const DefaultMaxAttempts = 5
+
+// DefaultMaxBackoff is the default ceiling on the delay between attempts.
+const DefaultMaxBackoff = 2 * time.Minute
+
+// initialBackoff is the delay before the first retry, which doubles with each
+// attempt after that.
+const initialBackoff = 3 * time.Second
+
 // Client is a Pinboard v1 API client.
 type Client struct {
 	authToken  string
@@ -28,6 +84,28 @@ type Client struct {
 	httpClient *http.Client
 	lastCall   time.Time
 	minDelay   time.Duration
+	allDelay   time.Duration
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) error
+
+	// Cache, if set, stores the results of posts/all, which are reused
+	// until posts/update shows that the account has changed.
+	Cache *Cache
+
+	// PageSize, if set, fetches bookmarks from posts/all that many at a
+	// time, rather than in a single response. Pinboard only allows one
+	// posts/all request every five minutes, so each page after the first
+	// waits that long: paging trades speed for smaller responses.
+	PageSize int
+
+	// MaxAttempts is how many times a request is tried before giving up when
+	// Pinboard returns 429 or a 5xx status, or the request fails to send.
+	// Values below 1 are treated as 1.
+	MaxAttempts int
+
+	// MaxBackoff caps the delay between attempts, unless Pinboard asks for a
+	// longer one with Retry-After. Zero means no ceiling.
+	MaxBackoff time.Duration
 }
 
 // NewClient creates a new Pinboard API client with the given auth token.
@@ -38,95 +116,192 @@ func NewClient(authToken string) *Client {
 		baseURL:    "https://api.pinboard.in/v1",
 		httpClient: &http.Client{},
 		minDelay:   3 * time.Second,
+		allDelay:   5 * time.Minute,
+		now:        time.Now,
+		sleep:      sleep,
+
+		MaxAttempts: DefaultMaxAttempts,
+		MaxBackoff:  DefaultMaxBackoff,
 	}
 }
 
 // GetBookmarkURLsByTag fetches all bookmark URLs that have the given tag.
 // Returns a set of URLs (map[string]bool) for efficient lookups.
 func (c *Client) GetBookmarkURLsByTag(ctx context.Context, tag string) (map[string]bool, error) {
-	// Construct the URL for the posts/all endpoint
-	apiURL := fmt.Sprintf("%s/posts/all", c.baseURL)
-
-	// Prepare query parameters
-	queryParams := url.Values{}
-	queryParams.Set("auth_token", c.authToken)
-	queryParams.Set("tag", tag)
-	queryParams.Set("format", "json")
+	// Build the URL set as the bookmarks arrive, without keeping the rest
+	urls := make(map[string]bool)
+	err := c.WalkBookmarksByTag(ctx, tag, func(b Bookmark) error {
+		urls[b.URL] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	// Construct the full URL with query parameters
-	fullURL := fmt.Sprintf("%s?%s", apiURL, queryParams.Encode())
+	return urls, nil
+}
 
-	// Create GET request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+// GetBookmarksByTag fetches all bookmarks that have the given tag, including
+// their title, description, and tags.
+func (c *Client) GetBookmarksByTag(ctx context.Context, tag string) ([]Bookmark, error) {
+	bookmarks := []Bookmark{}
+	err := c.WalkBookmarksByTag(ctx, tag, func(b Bookmark) error {
+		bookmarks = append(bookmarks, b)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	// Send request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	return bookmarks, nil
+}
+
+// WalkBookmarksByTag calls fn with each bookmark that has the given tag. The
+// bookmarks are decoded as they arrive rather than held in memory, and are
+// fetched PageSize at a time if it is set. With a Cache, they are only
+// fetched again once the account has changed. Walking stops at the first
+// error returned by fn.
+func (c *Client) WalkBookmarksByTag(ctx context.Context, tag string, fn func(Bookmark) error) error {
+	visit := func(p post) error {
+		return fn(p.bookmark())
 	}
-	defer resp.Body.Close()
 
-	// Check for HTTP 200 status
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	if c.Cache == nil {
+		return c.walkAllPosts(ctx, tag, visit)
 	}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	updated, err := c.LastUpdate(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
 
-	// Parse JSON response
-	var bookmarks []struct {
-		Href string `json:"href"`
-	}
-	if err := json.Unmarshal(body, &bookmarks); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	hit, err := c.Cache.walk(c.authToken, tag, updated, visit)
+	if hit || err != nil {
+		return err
 	}
 
-	// Build the URL set
-	urls := make(map[string]bool)
-	for _, bookmark := range bookmarks {
-		urls[bookmark.Href] = true
+	// Store the posts as they stream past. A failure to cache only costs a
+	// fetch next time.
+	w := c.Cache.create(c.authToken, tag, updated)
+	defer w.abort()
+
+	err = c.walkAllPosts(ctx, tag, func(p post) error {
+		w.add(p)
+		return visit(p)
+	})
+	if err != nil {
+		return err
 	}
 
-	return urls, nil
+	w.commit()
+	return nil
 }
 
-// AddBookmark creates or updates a bookmark on Pinboard.
-func (c *Client) AddBookmark(ctx context.Context, b Bookmark) error {
-	// Enforce rate limiting, respecting context cancellation.
-	if !c.lastCall.IsZero() {
-		elapsed := time.Since(c.lastCall)
-		if elapsed < c.minDelay {
-			timer := time.NewTimer(c.minDelay - elapsed)
-			select {
-			case <-ctx.Done():
-				timer.Stop()
-				return ctx.Err()
-			case <-timer.C:
+// walkAllPosts calls fn with every post with the given tag from posts/all,
+// a page at a time if PageSize is set. Pages are spaced out to respect
+// Pinboard's limit on posts/all requests.
+func (c *Client) walkAllPosts(ctx context.Context, tag string, fn func(post) error) error {
+	var lastPage time.Time
+
+	for start := 0; ; {
+		if !lastPage.IsZero() {
+			if err := c.sleep(ctx, c.allDelay-c.now().Sub(lastPage)); err != nil {
+				return err
 			}
 		}
+		lastPage = c.now()
+
+		queryParams := url.Values{}
+		queryParams.Set("tag", tag)
+		if c.PageSize > 0 {
+			queryParams.Set("start", strconv.Itoa(start))
+			queryParams.Set("results", strconv.Itoa(c.PageSize))
+		}
+
+		var count int
+		err := c.stream(ctx, "posts/all", queryParams, func(body io.Reader) error {
+			var err error
+			count, err = decodePosts(json.NewDecoder(body), fn)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		// A short page is the last one
+		if c.PageSize <= 0 || count < c.PageSize {
+			return nil
+		}
+		start += count
+	}
+}
+
+// decodePosts decodes a JSON array of posts one at a time, calling fn with
+// each, and returns how many there were.
+func decodePosts(dec *json.Decoder, fn func(post) error) (int, error) {
+	if err := expectDelim(dec, '['); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for dec.More() {
+		var p post
+		if err := dec.Decode(&p); err != nil {
+			return count, fmt.Errorf("failed to parse response: %w", err)
+		}
+		if err := fn(p); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if err := expectDelim(dec, ']'); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// expectDelim reads the next JSON token and returns an error unless it is
+// the given delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if tok != delim {
+		return fmt.Errorf("failed to parse response: expected %v, got %v", delim, tok)
 	}
-	c.lastCall = time.Now()
+	return nil
+}
 
-	// Construct the base URL for v1 posts/add endpoint
-	apiURL := fmt.Sprintf("%s/posts/add", c.baseURL)
+// LastUpdate returns the time of the most recent change to any bookmark in
+// the account, as reported by posts/update.
+func (c *Client) LastUpdate(ctx context.Context) (time.Time, error) {
+	body, err := c.call(ctx, "posts/update", url.Values{})
+	if err != nil {
+		return time.Time{}, err
+	}
 
+	var result struct {
+		UpdateTime time.Time `json:"update_time"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.UpdateTime, nil
+}
+
+// AddBookmark creates or updates a bookmark on Pinboard.
+func (c *Client) AddBookmark(ctx context.Context, b Bookmark) error {
 	// Prepare query parameters
 	queryParams := url.Values{}
-	queryParams.Set("auth_token", c.authToken)
 	queryParams.Set("url", b.URL)
 	queryParams.Set("description", b.Title)        // v1 API uses "description" for the title
 	queryParams.Set("extended", b.Description)     // v1 API uses "extended" for the description
 	queryParams.Set("tags", strings.Join(b.Tags, " "))
 	queryParams.Set("replace", "yes")              // Always yes for idempotent exports
-	queryParams.Set("format", "json")
 
 	// Map boolean fields to v1 API format
 	if b.Private {
@@ -141,41 +316,310 @@ func (c *Client) AddBookmark(ctx context.Context, b Bookmark) error {
 		queryParams.Set("toread", "no")
 	}
 
+	// The v1 API only accepts UTC times in the form 2006-01-02T15:04:05Z
+	if !b.Time.IsZero() {
+		queryParams.Set("dt", b.Time.UTC().Format(time.RFC3339))
+	}
+
+	body, err := c.call(ctx, "posts/add", queryParams)
+	if err != nil {
+		return err
+	}
+
+	return checkResultCode(body)
+}
+
+// DeleteBookmark removes the bookmark with the given URL from Pinboard.
+func (c *Client) DeleteBookmark(ctx context.Context, bookmarkURL string) error {
+	queryParams := url.Values{}
+	queryParams.Set("url", bookmarkURL)
+
+	body, err := c.call(ctx, "posts/delete", queryParams)
+	if err != nil {
+		return err
+	}
+
+	return checkResultCode(body)
+}
+
+// GetBookmark fetches the bookmark with the given URL. It returns ErrNotFound
+// if no such bookmark exists.
+func (c *Client) GetBookmark(ctx context.Context, bookmarkURL string) (Bookmark, error) {
+	queryParams := url.Values{}
+	queryParams.Set("url", bookmarkURL)
+	queryParams.Set("meta", "yes")
+
+	bookmarks, err := c.getPosts(ctx, "posts/get", queryParams)
+	if err != nil {
+		return Bookmark{}, err
+	}
+
+	if len(bookmarks) == 0 {
+		return Bookmark{}, ErrNotFound
+	}
+
+	return bookmarks[0], nil
+}
+
+// GetBookmarksByDate fetches the bookmarks created on the given day, in UTC,
+// optionally limited to those with all of the given tags (up to three).
+func (c *Client) GetBookmarksByDate(ctx context.Context, day time.Time, tags ...string) ([]Bookmark, error) {
+	queryParams := url.Values{}
+	queryParams.Set("dt", day.UTC().Format(time.DateOnly))
+	queryParams.Set("meta", "yes")
+	if len(tags) > 0 {
+		queryParams.Set("tag", strings.Join(tags, " "))
+	}
+
+	return c.getPosts(ctx, "posts/get", queryParams)
+}
+
+// GetRecentBookmarks fetches the most recent bookmarks, newest first,
+// optionally limited to those with all of the given tags (up to three).
+// Pinboard returns 15 if count is zero, and no more than 100. It asks
+// clients to call posts/recent no more than once a minute.
+func (c *Client) GetRecentBookmarks(ctx context.Context, count int, tags ...string) ([]Bookmark, error) {
+	queryParams := url.Values{}
+	if count > 0 {
+		queryParams.Set("count", strconv.Itoa(count))
+	}
+	if len(tags) > 0 {
+		queryParams.Set("tag", strings.Join(tags, " "))
+	}
+
+	return c.getPosts(ctx, "posts/recent", queryParams)
+}
+
+// getPosts calls an endpoint that returns a list of posts, such as posts/get
+// and posts/recent, and converts them to bookmarks.
+func (c *Client) getPosts(ctx context.Context, endpoint string, queryParams url.Values) ([]Bookmark, error) {
+	body, err := c.call(ctx, endpoint, queryParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Posts []post `json:"posts"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	bookmarks := make([]Bookmark, len(result.Posts))
+	for i, p := range result.Posts {
+		bookmarks[i] = p.bookmark()
+	}
+
+	return bookmarks, nil
+}
+
+// APIToken fetches the API token of the authenticated user, as used in the
+// auth_token parameter.
+func (c *Client) APIToken(ctx context.Context) (string, error) {
+	body, err := c.call(ctx, "user/api_token", url.Values{})
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Result string `json:"result"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return result.Result, nil
+}
+
+// call sends a rate-limited GET request to the given v1 API endpoint and
+// returns the response body. The auth token and JSON format parameters are
+// added automatically.
+func (c *Client) call(ctx context.Context, endpoint string, queryParams url.Values) ([]byte, error) {
+	var body []byte
+	err := c.stream(ctx, endpoint, queryParams, func(r io.Reader) error {
+		var err error
+		if body, err = io.ReadAll(r); err != nil {
+			return &retryableError{err: fmt.Errorf("failed to read response: %w", err)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// stream sends a rate-limited GET request to the given v1 API endpoint and
+// passes the body of a successful response to handle. Transient failures are
+// retried with exponential backoff, up to MaxAttempts times. Errors from
+// handle are only retried if they are a *retryableError, so handle must not
+// have acted on a partial response before returning one.
+func (c *Client) stream(ctx context.Context, endpoint string, queryParams url.Values, handle func(io.Reader) error) error {
+	queryParams.Set("auth_token", c.authToken)
+	queryParams.Set("format", "json")
+
 	// Construct the full URL with query parameters
-	fullURL := fmt.Sprintf("%s?%s", apiURL, queryParams.Encode())
+	fullURL := fmt.Sprintf("%s/%s?%s", c.baseURL, endpoint, queryParams.Encode())
+
+	maxAttempts := max(c.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		if err := c.wait(ctx); err != nil {
+			return err
+		}
 
+		err := c.send(ctx, fullURL, handle)
+
+		var retry *retryableError
+		if !errors.As(err, &retry) {
+			return err
+		}
+
+		if attempt == maxAttempts {
+			if attempt > 1 {
+				return fmt.Errorf("giving up after %d attempts: %w", attempt, retry.err)
+			}
+			return retry.err
+		}
+
+		delay := retry.retryAfter
+		if delay == 0 {
+			delay = c.backoff(attempt)
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// retryableError is a failure that may succeed if the request is retried.
+type retryableError struct {
+	err error
+
+	// retryAfter is the delay Pinboard asked for, if any.
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// send makes a single GET request and passes the body of a successful
+// response to handle. Failures worth retrying are returned as a
+// *retryableError.
+func (c *Client) send(ctx context.Context, fullURL string, handle func(io.Reader) error) error {
 	// Create GET request (v1 API uses GET, not POST)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Send request
+	// Send request, retrying network errors unless we were cancelled
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		err = fmt.Errorf("failed to send request: %w", err)
+		if ctx.Err() != nil {
+			return err
+		}
+		return &retryableError{err: err}
 	}
 	defer resp.Body.Close()
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+	// Check for HTTP 200 status, retrying rate limits and server errors
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return &retryableError{err: err, retryAfter: c.retryAfter(resp)}
+		}
+		return err
 	}
 
-	// Check for HTTP 200 status
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	return handle(resp.Body)
+}
+
+// retryAfter returns the delay requested by a response's Retry-After header,
+// which may be a number of seconds or a date, or zero if there isn't one.
+func (c *Client) retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
 	}
 
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(c.now()), 0)
+	}
+
+	return 0
+}
+
+// backoff returns the delay before retrying after the given attempt. The
+// delay doubles with each attempt up to MaxBackoff, and is jittered so that
+// clients don't retry in lockstep.
+func (c *Client) backoff(attempt int) time.Duration {
+	// Stop doubling long before the delay could overflow
+	delay := initialBackoff << min(attempt-1, 16)
+	if c.MaxBackoff > 0 {
+		delay = min(delay, c.MaxBackoff)
+	}
+
+	// Wait at least half the delay, and a random amount of the rest
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// wait enforces the minimum delay between API calls, respecting context
+// cancellation.
+func (c *Client) wait(ctx context.Context) error {
+	if !c.lastCall.IsZero() {
+		elapsed := c.now().Sub(c.lastCall)
+		if elapsed < c.minDelay {
+			if err := c.sleep(ctx, c.minDelay-elapsed); err != nil {
+				return err
+			}
+		}
+	}
+	c.lastCall = c.now()
+
+	return nil
+}
+
+// sleep pauses for d, returning early with ctx's error if ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// checkResultCode parses a v1 API result and returns an error unless the
+// result_code is "done". The tags endpoints call it result instead.
+func checkResultCode(body []byte) error {
 	// Parse JSON response
 	var result struct {
 		ResultCode string `json:"result_code"`
+		Result     string `json:"result"`
 	}
 	if err := json.Unmarshal(body, &result); err != nil {
 		return fmt.Errorf("failed to parse response: %w", err)
 	}
 
+	if result.ResultCode == "" {
+		result.ResultCode = result.Result
+	}
+
 	// Check for success result_code
 	if result.ResultCode != "done" {
 		return fmt.Errorf("API error: result_code was %q", result.ResultCode)
@@ -3,6 +3,8 @@ package pinboard
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -184,6 +186,42 @@ func TestBooleanFields(t *testing.T) {
 	}
 }
 
+// TestBookmarkTime verifies that a bookmark's time is sent as dt in UTC, and
+// omitted when zero.
+func TestBookmarkTime(t *testing.T) {
+	var receivedQueryParams url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedQueryParams = r.URL.Query()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"result_code": "done"})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+	client.minDelay = 0
+
+	starred := time.Date(2023, 6, 1, 14, 30, 0, 0, time.FixedZone("BST", 3600))
+	err := client.AddBookmark(context.Background(), Bookmark{URL: "https://example.com", Time: starred})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := receivedQueryParams.Get("dt"); got != "2023-06-01T13:30:00Z" {
+		t.Errorf("expected dt=2023-06-01T13:30:00Z, got %q", got)
+	}
+
+	err = client.AddBookmark(context.Background(), Bookmark{URL: "https://example.com"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if receivedQueryParams.Has("dt") {
+		t.Errorf("expected no dt for a zero time, got %q", receivedQueryParams.Get("dt"))
+	}
+}
+
 // TestServerErrorResponse verifies that server errors return meaningful error messages.
 func TestServerErrorResponse(t *testing.T) {
 	tests := []struct {
@@ -223,6 +261,7 @@ func TestServerErrorResponse(t *testing.T) {
 
 			client := NewClient("test_token")
 			client.baseURL = server.URL
+			newFakeClock(client)
 
 			bookmark := Bookmark{
 				URL:   "https://example.com",
@@ -494,6 +533,7 @@ func TestGetBookmarkURLsByTagHTTPError(t *testing.T) {
 
 	client := NewClient("test_token")
 	client.baseURL = server.URL
+	newFakeClock(client)
 
 	_, err := client.GetBookmarkURLsByTag(context.Background(), "golang")
 	if err == nil {
@@ -505,3 +545,680 @@ func TestGetBookmarkURLsByTagHTTPError(t *testing.T) {
 		t.Errorf("expected error to contain status code 500, got %q", err.Error())
 	}
 }
+
+// TestDeleteBookmark verifies that posts/delete is called with the bookmark URL.
+func TestDeleteBookmark(t *testing.T) {
+	var receivedPath string
+	var receivedQueryParams url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedQueryParams = r.URL.Query()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"result_code": "done"})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	err := client.DeleteBookmark(context.Background(), "https://github.com/foo/bar")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if receivedPath != "/posts/delete" {
+		t.Errorf("expected path /posts/delete, got %q", receivedPath)
+	}
+	if receivedQueryParams.Get("url") != "https://github.com/foo/bar" {
+		t.Errorf("expected url param, got %q", receivedQueryParams.Get("url"))
+	}
+	if receivedQueryParams.Get("auth_token") != "test_token" {
+		t.Errorf("expected auth_token=test_token, got %q", receivedQueryParams.Get("auth_token"))
+	}
+}
+
+// TestDeleteBookmarkNotFound verifies that a non-"done" result code is an error.
+func TestDeleteBookmarkNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"result_code": "item not found"})
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	err := client.DeleteBookmark(context.Background(), "https://github.com/foo/bar")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !containsSubstring(err.Error(), "item not found") {
+		t.Errorf("expected error to mention result code, got %q", err.Error())
+	}
+}
+
+// TestGetBookmark verifies that posts/get responses are mapped back to a Bookmark.
+func TestGetBookmark(t *testing.T) {
+	var receivedPath string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"date": "2024-01-01T00:00:00Z",
+			"user": "test",
+			"posts": [{
+				"href": "https://github.com/foo/bar",
+				"description": "foo/bar",
+				"extended": "A repository",
+				"tags": "github-repo go cli",
+				"shared": "no",
+				"toread": "yes",
+				"time": "2023-06-01T13:30:00Z",
+				"hash": "0c9c6d4b2a7e3f1d8e5a6b7c9d0e1f2a",
+				"meta": "5f4dcc3b5aa765d61d8327deb882cf99"
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	bookmark, err := client.GetBookmark(context.Background(), "https://github.com/foo/bar")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if receivedPath != "/posts/get" {
+		t.Errorf("expected path /posts/get, got %q", receivedPath)
+	}
+	if bookmark.URL != "https://github.com/foo/bar" {
+		t.Errorf("expected URL %q, got %q", "https://github.com/foo/bar", bookmark.URL)
+	}
+	if bookmark.Title != "foo/bar" {
+		t.Errorf("expected Title %q, got %q", "foo/bar", bookmark.Title)
+	}
+	if bookmark.Description != "A repository" {
+		t.Errorf("expected Description %q, got %q", "A repository", bookmark.Description)
+	}
+	if len(bookmark.Tags) != 3 || bookmark.Tags[0] != "github-repo" || bookmark.Tags[2] != "cli" {
+		t.Errorf("expected tags [github-repo go cli], got %v", bookmark.Tags)
+	}
+	if !bookmark.Private {
+		t.Error("expected Private to be true")
+	}
+	if !bookmark.ToRead {
+		t.Error("expected ToRead to be true")
+	}
+	if !bookmark.Time.Equal(time.Date(2023, 6, 1, 13, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected Time 2023-06-01T13:30:00Z, got %v", bookmark.Time)
+	}
+	if bookmark.Hash != "0c9c6d4b2a7e3f1d8e5a6b7c9d0e1f2a" || bookmark.Meta != "5f4dcc3b5aa765d61d8327deb882cf99" {
+		t.Errorf("expected Hash and Meta to be set, got %q and %q", bookmark.Hash, bookmark.Meta)
+	}
+}
+
+// TestGetBookmarkNotFound verifies that an empty posts list returns ErrNotFound.
+func TestGetBookmarkNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"date": "2024-01-01T00:00:00Z", "user": "test", "posts": []}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	_, err := client.GetBookmark(context.Background(), "https://github.com/foo/bar")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestGetBookmarksByTag verifies that posts/all responses are mapped to full bookmarks.
+func TestGetBookmarksByTag(t *testing.T) {
+	var receivedPath string
+	var receivedQueryParams url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedQueryParams = r.URL.Query()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{"href": "https://github.com/foo/bar", "description": "foo/bar", "extended": "Foo", "tags": "github-repo go", "shared": "no", "toread": "no"},
+			{"href": "https://github.com/baz/qux", "description": "baz/qux", "extended": "", "tags": "github-repo", "shared": "yes", "toread": "yes"}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	bookmarks, err := client.GetBookmarksByTag(context.Background(), "github-repo")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if receivedPath != "/posts/all" {
+		t.Errorf("expected path /posts/all, got %q", receivedPath)
+	}
+	if receivedQueryParams.Get("tag") != "github-repo" {
+		t.Errorf("expected tag=github-repo, got %q", receivedQueryParams.Get("tag"))
+	}
+
+	if len(bookmarks) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %d", len(bookmarks))
+	}
+	if bookmarks[0].Title != "foo/bar" || bookmarks[0].Description != "Foo" {
+		t.Errorf("unexpected first bookmark %+v", bookmarks[0])
+	}
+	if len(bookmarks[0].Tags) != 2 || bookmarks[0].Tags[1] != "go" {
+		t.Errorf("expected tags [github-repo go], got %v", bookmarks[0].Tags)
+	}
+	if !bookmarks[0].Private || bookmarks[0].ToRead {
+		t.Errorf("expected first bookmark private and not to-read, got %+v", bookmarks[0])
+	}
+	if bookmarks[1].Private || !bookmarks[1].ToRead {
+		t.Errorf("expected second bookmark shared and to-read, got %+v", bookmarks[1])
+	}
+}
+
+// fakeClock stands in for the client's clock, so that tests of waiting and
+// retrying don't have to wait. Sleeping advances the clock.
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// newFakeClock installs a fake clock on the client.
+func newFakeClock(client *Client) *fakeClock {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	client.now = func() time.Time { return clock.now }
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		clock.sleeps = append(clock.sleeps, d)
+		clock.now = clock.now.Add(d)
+		return ctx.Err()
+	}
+	return clock
+}
+
+// TestRetryTransientFailures verifies that 429, 5xx and network failures are
+// retried until the request succeeds.
+func TestRetryTransientFailures(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func(w http.ResponseWriter)
+	}{
+		{
+			name: "too many requests",
+			fail: func(w http.ResponseWriter) { w.WriteHeader(http.StatusTooManyRequests) },
+		},
+		{
+			name: "server error",
+			fail: func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+		},
+		{
+			name: "network error",
+			fail: func(w http.ResponseWriter) {
+				// Drop the connection without responding
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests < 3 {
+					tt.fail(w)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]string{"result_code": "done"})
+			}))
+			defer server.Close()
+
+			client := NewClient("test_token")
+			client.baseURL = server.URL
+			client.minDelay = 0
+			clock := newFakeClock(client)
+
+			err := client.AddBookmark(context.Background(), Bookmark{URL: "https://example.com", Title: "Test"})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if requests != 3 {
+				t.Errorf("expected 3 requests, got %d", requests)
+			}
+			if len(clock.sleeps) != 2 {
+				t.Fatalf("expected 2 backoff delays, got %v", clock.sleeps)
+			}
+			// The first delay is 3s and the second 6s, less up to half for jitter
+			if clock.sleeps[0] < 1500*time.Millisecond || clock.sleeps[0] > 3*time.Second {
+				t.Errorf("expected first delay between 1.5s and 3s, got %v", clock.sleeps[0])
+			}
+			if clock.sleeps[1] < 3*time.Second || clock.sleeps[1] > 6*time.Second {
+				t.Errorf("expected second delay between 3s and 6s, got %v", clock.sleeps[1])
+			}
+		})
+	}
+}
+
+// TestRetryGivesUp verifies that a request is tried MaxAttempts times, with
+// delays capped at MaxBackoff, before its error is returned.
+func TestRetryGivesUp(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+	client.MaxAttempts = 6
+	client.MaxBackoff = 10 * time.Second
+	clock := newFakeClock(client)
+
+	_, err := client.GetBookmark(context.Background(), "https://example.com")
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !containsSubstring(err.Error(), "giving up after 6 attempts: API returned status 503") {
+		t.Errorf("unexpected error %q", err.Error())
+	}
+
+	if requests != 6 {
+		t.Errorf("expected 6 requests, got %d", requests)
+	}
+	for _, delay := range clock.sleeps {
+		if delay > client.MaxBackoff {
+			t.Errorf("expected delays of at most %v, got %v", client.MaxBackoff, delay)
+		}
+	}
+}
+
+// TestRetryAfter verifies that Pinboard's requested delay is used instead of
+// the backoff, in both of its formats.
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter func(now time.Time) string
+	}{
+		{
+			name:       "seconds",
+			retryAfter: func(now time.Time) string { return "42" },
+		},
+		{
+			name:       "date",
+			retryAfter: func(now time.Time) string { return now.Add(42 * time.Second).Format(http.TimeFormat) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var clock *fakeClock
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter(clock.now))
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]string{"result_code": "done"})
+			}))
+			defer server.Close()
+
+			client := NewClient("test_token")
+			client.baseURL = server.URL
+			clock = newFakeClock(client)
+
+			if err := client.DeleteBookmark(context.Background(), "https://example.com"); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if len(clock.sleeps) != 1 || clock.sleeps[0] != 42*time.Second {
+				t.Errorf("expected a single 42s delay, got %v", clock.sleeps)
+			}
+		})
+	}
+}
+
+// TestNoRetryOnClientError verifies that other errors are returned at once.
+func TestNoRetryOnClientError(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+	newFakeClock(client)
+
+	if _, err := client.GetBookmark(context.Background(), "https://example.com"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+// TestRetryRespectsContext verifies that a cancelled context stops retrying.
+func TestRetryRespectsContext(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+	client.minDelay = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetBookmark(ctx, "https://example.com")
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed >= 1*time.Second {
+		t.Errorf("expected prompt return on cancel, but waited %v", elapsed)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+// TestGetBookmarksByTagPaging verifies that posts/all is walked PageSize
+// bookmarks at a time until a short page.
+func TestGetBookmarksByTagPaging(t *testing.T) {
+	all := []string{"one", "two", "three", "four", "five"}
+	var starts []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		starts = append(starts, query.Get("start"))
+		if query.Get("results") != "2" {
+			t.Errorf("expected results=2, got %q", query.Get("results"))
+		}
+
+		var start int
+		fmt.Sscan(query.Get("start"), &start)
+		page := []map[string]string{}
+		for i := start; i < len(all) && i < start+2; i++ {
+			page = append(page, map[string]string{"href": "https://github.com/foo/" + all[i]})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+	client.minDelay = 0
+	client.PageSize = 2
+	clock := newFakeClock(client)
+
+	bookmarks, err := client.GetBookmarksByTag(context.Background(), "github-repo")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(starts) != 3 || starts[0] != "0" || starts[1] != "2" || starts[2] != "4" {
+		t.Errorf("expected pages starting at 0, 2 and 4, got %v", starts)
+	}
+	// Pinboard allows one posts/all request every five minutes
+	if len(clock.sleeps) != 2 || clock.sleeps[0] != 5*time.Minute || clock.sleeps[1] != 5*time.Minute {
+		t.Errorf("expected two 5m waits between pages, got %v", clock.sleeps)
+	}
+	if len(bookmarks) != 5 || bookmarks[4].URL != "https://github.com/foo/five" {
+		t.Errorf("expected all 5 bookmarks in order, got %+v", bookmarks)
+	}
+}
+
+// TestGetBookmarksByTagNoPaging verifies that posts/all is fetched in one go
+// without a PageSize.
+func TestGetBookmarksByTagNoPaging(t *testing.T) {
+	var receivedQueryParams url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedQueryParams = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"href": "https://github.com/foo/bar"}, {"href": "https://github.com/baz/qux"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	bookmarks, err := client.GetBookmarksByTag(context.Background(), "github-repo")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if receivedQueryParams.Has("start") || receivedQueryParams.Has("results") {
+		t.Errorf("expected no paging parameters, got %v", receivedQueryParams)
+	}
+	if len(bookmarks) != 2 {
+		t.Errorf("expected 2 bookmarks, got %d", len(bookmarks))
+	}
+}
+
+// TestWalkBookmarksByTag verifies that bookmarks are passed on one at a time
+// and that an error from the callback stops the walk.
+func TestWalkBookmarksByTag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"href": "https://github.com/foo/bar"}, {"href": "https://github.com/baz/qux"}, {"href": "https://github.com/a/b"}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	stop := errors.New("stop")
+	var seen []string
+	err := client.WalkBookmarksByTag(context.Background(), "github-repo", func(b Bookmark) error {
+		seen = append(seen, b.URL)
+		if len(seen) == 2 {
+			return stop
+		}
+		return nil
+	})
+
+	if !errors.Is(err, stop) {
+		t.Errorf("expected the callback's error, got %v", err)
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected the walk to stop after 2 bookmarks, got %v", seen)
+	}
+}
+
+// TestGetBookmarksByTagInvalidJSON verifies that a malformed response is an
+// error.
+func TestGetBookmarksByTagInvalidJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "not an array", body: `{"href": "https://github.com/foo/bar"}`},
+		{name: "truncated", body: `[{"href": "https://github.com/foo/bar"}, {"hr`},
+		{name: "bad element", body: `[{"href": 42}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient("test_token")
+			client.baseURL = server.URL
+
+			_, err := client.GetBookmarksByTag(context.Background(), "github-repo")
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !containsSubstring(err.Error(), "failed to parse response") {
+				t.Errorf("expected a parse error, got %q", err.Error())
+			}
+		})
+	}
+}
+
+// TestGetBookmarksByDate verifies that posts/get is asked for a day's
+// bookmarks with the given tags.
+func TestGetBookmarksByDate(t *testing.T) {
+	var receivedPath string
+	var receivedQueryParams url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedQueryParams = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"date": "2024-03-01T00:00:00Z", "user": "test", "posts": [
+			{"href": "https://github.com/foo/bar", "description": "foo/bar", "tags": "github-repo go", "time": "2024-03-01T09:00:00Z", "hash": "h1", "meta": "m1"},
+			{"href": "https://github.com/baz/qux", "description": "baz/qux", "tags": "github-repo go", "time": "2024-03-01T18:00:00Z", "hash": "h2", "meta": "m2"}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	bookmarks, err := client.GetBookmarksByDate(context.Background(), day, "github-repo", "go")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if receivedPath != "/posts/get" {
+		t.Errorf("expected path /posts/get, got %q", receivedPath)
+	}
+	if receivedQueryParams.Get("dt") != "2024-03-01" {
+		t.Errorf("expected dt=2024-03-01, got %q", receivedQueryParams.Get("dt"))
+	}
+	if receivedQueryParams.Get("tag") != "github-repo go" {
+		t.Errorf("expected tag=github-repo go, got %q", receivedQueryParams.Get("tag"))
+	}
+	if receivedQueryParams.Get("meta") != "yes" {
+		t.Errorf("expected meta=yes, got %q", receivedQueryParams.Get("meta"))
+	}
+
+	if len(bookmarks) != 2 {
+		t.Fatalf("expected 2 bookmarks, got %d", len(bookmarks))
+	}
+	if bookmarks[1].URL != "https://github.com/baz/qux" || bookmarks[1].Hash != "h2" || bookmarks[1].Meta != "m2" {
+		t.Errorf("unexpected second bookmark %+v", bookmarks[1])
+	}
+}
+
+// TestGetRecentBookmarks verifies that posts/recent is called with the count
+// and tags, and its posts are mapped to bookmarks.
+func TestGetRecentBookmarks(t *testing.T) {
+	tests := []struct {
+		name          string
+		count         int
+		tags          []string
+		expectedCount string
+		expectedTag   string
+	}{
+		{name: "defaults", count: 0},
+		{name: "count and tags", count: 50, tags: []string{"go"}, expectedCount: "50", expectedTag: "go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedPath string
+			var receivedQueryParams url.Values
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedPath = r.URL.Path
+				receivedQueryParams = r.URL.Query()
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"date": "2024-03-01T18:00:00Z", "user": "test", "posts": [
+					{"href": "https://github.com/foo/bar", "description": "foo/bar", "tags": "go", "shared": "yes", "toread": "no", "time": "2024-03-01T18:00:00Z", "hash": "h1", "meta": "m1"}
+				]}`))
+			}))
+			defer server.Close()
+
+			client := NewClient("test_token")
+			client.baseURL = server.URL
+
+			bookmarks, err := client.GetRecentBookmarks(context.Background(), tt.count, tt.tags...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if receivedPath != "/posts/recent" {
+				t.Errorf("expected path /posts/recent, got %q", receivedPath)
+			}
+			if receivedQueryParams.Get("count") != tt.expectedCount {
+				t.Errorf("expected count=%q, got %q", tt.expectedCount, receivedQueryParams.Get("count"))
+			}
+			if receivedQueryParams.Get("tag") != tt.expectedTag {
+				t.Errorf("expected tag=%q, got %q", tt.expectedTag, receivedQueryParams.Get("tag"))
+			}
+
+			if len(bookmarks) != 1 || bookmarks[0].Private || bookmarks[0].Hash != "h1" {
+				t.Errorf("unexpected bookmarks %+v", bookmarks)
+			}
+			if !bookmarks[0].Time.Equal(time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)) {
+				t.Errorf("expected Time 2024-03-01T18:00:00Z, got %v", bookmarks[0].Time)
+			}
+		})
+	}
+}
+
+// TestAPIToken verifies that user/api_token is parsed.
+func TestAPIToken(t *testing.T) {
+	var receivedPath string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result": "ABCDEF0123456789"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("user:token")
+	client.baseURL = server.URL
+
+	token, err := client.APIToken(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if receivedPath != "/user/api_token" {
+		t.Errorf("expected path /user/api_token, got %q", receivedPath)
+	}
+	if token != "ABCDEF0123456789" {
+		t.Errorf("expected token ABCDEF0123456789, got %q", token)
+	}
+}
@@ -0,0 +1,105 @@
+package pinboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// TagSuggestions holds the tags Pinboard suggests for a URL.
+type TagSuggestions struct {
+	// Popular lists tags other users commonly give the URL.
+	Popular []string
+	// Recommended lists tags drawn from the user's own tags.
+	Recommended []string
+}
+
+// SuggestTags fetches popular and recommended tags for the given URL.
+func (c *Client) SuggestTags(ctx context.Context, bookmarkURL string) (TagSuggestions, error) {
+	queryParams := url.Values{}
+	queryParams.Set("url", bookmarkURL)
+
+	body, err := c.call(ctx, "posts/suggest", queryParams)
+	if err != nil {
+		return TagSuggestions{}, err
+	}
+
+	// The response is a list of single-key objects, one per kind
+	var result []struct {
+		Popular     []string `json:"popular"`
+		Recommended []string `json:"recommended"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return TagSuggestions{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	suggestions := TagSuggestions{Popular: []string{}, Recommended: []string{}}
+	for _, r := range result {
+		suggestions.Popular = append(suggestions.Popular, r.Popular...)
+		suggestions.Recommended = append(suggestions.Recommended, r.Recommended...)
+	}
+
+	return suggestions, nil
+}
+
+// GetTags fetches every tag in the account, with the number of bookmarks
+// using each.
+func (c *Client) GetTags(ctx context.Context) (map[string]int, error) {
+	body, err := c.call(ctx, "tags/get", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Counts may be sent as numbers or strings
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	tags := make(map[string]int, len(result))
+	for tag, raw := range result {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			raw = json.RawMessage(s)
+		}
+
+		count, err := strconv.Atoi(string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse response: invalid count for tag %q", tag)
+		}
+		tags[tag] = count
+	}
+
+	return tags, nil
+}
+
+// RenameTag renames a tag on every bookmark that uses it. Pinboard matches
+// the old name case-insensitively, so this can also change a tag's case.
+func (c *Client) RenameTag(ctx context.Context, oldName, newName string) error {
+	queryParams := url.Values{}
+	queryParams.Set("old", oldName)
+	queryParams.Set("new", newName)
+
+	body, err := c.call(ctx, "tags/rename", queryParams)
+	if err != nil {
+		return err
+	}
+
+	return checkResultCode(body)
+}
+
+// DeleteTag removes a tag from every bookmark that uses it. The bookmarks
+// themselves are kept.
+func (c *Client) DeleteTag(ctx context.Context, tag string) error {
+	queryParams := url.Values{}
+	queryParams.Set("tag", tag)
+
+	body, err := c.call(ctx, "tags/delete", queryParams)
+	if err != nil {
+		return err
+	}
+
+	return checkResultCode(body)
+}
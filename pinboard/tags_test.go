@@ -0,0 +1,170 @@
+package pinboard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestSuggestTags verifies that popular and recommended tags are separated.
+func TestSuggestTags(t *testing.T) {
+	var receivedPath string
+	var receivedQueryParams url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedQueryParams = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"popular": ["go", "golang"]}, {"recommended": ["programming", "go"]}]`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	suggestions, err := client.SuggestTags(context.Background(), "https://github.com/golang/go")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if receivedPath != "/posts/suggest" {
+		t.Errorf("expected path /posts/suggest, got %q", receivedPath)
+	}
+	if receivedQueryParams.Get("url") != "https://github.com/golang/go" {
+		t.Errorf("expected url parameter, got %q", receivedQueryParams.Get("url"))
+	}
+	if got := strings.Join(suggestions.Popular, " "); got != "go golang" {
+		t.Errorf("expected popular [go golang], got %v", suggestions.Popular)
+	}
+	if got := strings.Join(suggestions.Recommended, " "); got != "programming go" {
+		t.Errorf("expected recommended [programming go], got %v", suggestions.Recommended)
+	}
+}
+
+// TestGetTags verifies that tag counts are parsed, whether Pinboard sends
+// them as numbers or strings.
+func TestGetTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "numbers", body: `{"github-repo": 120, "go": 7}`},
+		{name: "strings", body: `{"github-repo": "120", "go": "7"}`},
+		{name: "invalid", body: `{"github-repo": "many"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedPath = r.URL.Path
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient("test_token")
+			client.baseURL = server.URL
+
+			tags, err := client.GetTags(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if receivedPath != "/tags/get" {
+				t.Errorf("expected path /tags/get, got %q", receivedPath)
+			}
+			if len(tags) != 2 || tags["github-repo"] != 120 || tags["go"] != 7 {
+				t.Errorf("unexpected tags %v", tags)
+			}
+		})
+	}
+}
+
+// TestRenameTag verifies that tags/rename is called with the old and new
+// names.
+func TestRenameTag(t *testing.T) {
+	var receivedPath string
+	var receivedQueryParams url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedPath = r.URL.Path
+		receivedQueryParams = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"result": "done"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test_token")
+	client.baseURL = server.URL
+
+	if err := client.RenameTag(context.Background(), "golang", "go"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if receivedPath != "/tags/rename" {
+		t.Errorf("expected path /tags/rename, got %q", receivedPath)
+	}
+	if receivedQueryParams.Get("old") != "golang" || receivedQueryParams.Get("new") != "go" {
+		t.Errorf("expected old=golang and new=go, got %v", receivedQueryParams)
+	}
+}
+
+// TestDeleteTag verifies that tags/delete is called with the tag, and that a
+// failed result is an error.
+func TestDeleteTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "done", body: `{"result": "done"}`},
+		{name: "failed", body: `{"result": "something went wrong"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedPath string
+			var receivedQueryParams url.Values
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedPath = r.URL.Path
+				receivedQueryParams = r.URL.Query()
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient("test_token")
+			client.baseURL = server.URL
+
+			err := client.DeleteTag(context.Background(), "obsolete")
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "something went wrong") {
+					t.Errorf("expected an error mentioning the result, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if receivedPath != "/tags/delete" {
+				t.Errorf("expected path /tags/delete, got %q", receivedPath)
+			}
+			if receivedQueryParams.Get("tag") != "obsolete" {
+				t.Errorf("expected tag=obsolete, got %q", receivedQueryParams.Get("tag"))
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Entry records a single exported repository.
+type Entry struct {
+	Name      string    `json:"name"`
+	StarredAt time.Time `json:"starred_at"`
+	Hash      string    `json:"hash"`
+
+	// RepoID is GitHub's ID for the repository, which survives renames and
+	// transfers. It is zero for entries recorded before IDs were kept.
+	RepoID int64 `json:"repo_id,omitempty"`
+
+	// Tags are the tags gitboard generated for the bookmark, so merging can
+	// tell them apart from tags the user added. It is empty for entries
+	// recorded before tags were kept.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Store is a local record of previously exported repositories, keyed by
+// bookmark URL. It lets incremental runs skip work that has already been done.
+type Store struct {
+	path    string
+	LastRun time.Time        `json:"last_run"`
+	Entries map[string]Entry `json:"entries"`
+
+	// Filter fingerprints the filter used by the last run. Stars it left out
+	// were never exported, so a run with a different filter must look at
+	// every star again.
+	Filter string `json:"filter,omitempty"`
+}
+
+// DefaultDir returns the directory gitboard keeps its state in, following the
+// XDG base directory specification: $XDG_STATE_HOME/gitboard, falling back to
+// ~/.local/state/gitboard.
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "gitboard"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".local", "state", "gitboard"), nil
+}
+
+// AccountDir returns the directory under base for the state of the given
+// config profile and GitHub user, so that an incremental run for one never
+// skips stars that belong to another, and a checkpoint is only resumed with
+// the account it was made for. Without a profile or user, it returns base.
+func AccountDir(base, profile, githubUser string) string {
+	dir := base
+	if profile != "" {
+		dir = filepath.Join(dir, "profiles", profile)
+	}
+	if githubUser != "" {
+		dir = filepath.Join(dir, "users", githubUser)
+	}
+	return dir
+}
+
+// Load reads the state file at path. A missing file is not an error; it
+// returns an empty store that will be created on Save.
+func Load(path string) (*Store, error) {
+	s := &Store{path: path, Entries: map[string]Entry{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+
+	// Ensure Entries is never nil, even if the file omitted it
+	if s.Entries == nil {
+		s.Entries = map[string]Entry{}
+	}
+
+	return s, nil
+}
+
+// Save writes the store back to the file it was loaded from. The file is
+// replaced atomically so an interrupted write never corrupts existing state.
+func (s *Store) Save() error {
+	return WriteJSON(s.path, s)
+}
+
+// Get returns the entry for the given bookmark URL, if one exists.
+func (s *Store) Get(url string) (Entry, bool) {
+	e, ok := s.Entries[url]
+	return e, ok
+}
+
+// Put records an entry for the given bookmark URL, replacing any existing one.
+func (s *Store) Put(url string, e Entry) {
+	s.Entries[url] = e
+}
+
+// Remove deletes the entry for the given bookmark URL.
+func (s *Store) Remove(url string) {
+	delete(s.Entries, url)
+}
+
+// WriteJSON marshals v to path via a temporary file and rename, creating the
+// parent directory if needed.
+func WriteJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package state
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLoadMissingFile verifies that a missing state file yields an empty store.
+func TestLoadMissingFile(t *testing.T) {
+	s, err := Load(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !s.LastRun.IsZero() {
+		t.Errorf("expected zero LastRun, got %v", s.LastRun)
+	}
+	if s.Entries == nil || len(s.Entries) != 0 {
+		t.Errorf("expected empty non-nil entries, got %v", s.Entries)
+	}
+}
+
+// TestSaveAndLoad verifies that a saved store round-trips through disk.
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	lastRun := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	starredAt := time.Date(2023, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.LastRun = lastRun
+	s.Put("https://github.com/a/b", Entry{Name: "a/b", StarredAt: starredAt, Hash: "abc123"})
+	s.Put("https://github.com/c/d", Entry{Name: "c/d"})
+	s.Remove("https://github.com/c/d")
+
+	if err := s.Save(); err != nil {
+		t.Fatalf("unexpected error saving: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading: %v", err)
+	}
+
+	if !loaded.LastRun.Equal(lastRun) {
+		t.Errorf("expected LastRun %v, got %v", lastRun, loaded.LastRun)
+	}
+	if len(loaded.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(loaded.Entries))
+	}
+
+	e, ok := loaded.Get("https://github.com/a/b")
+	if !ok {
+		t.Fatal("expected entry for a/b")
+	}
+	if e.Name != "a/b" || e.Hash != "abc123" || !e.StarredAt.Equal(starredAt) {
+		t.Errorf("unexpected entry %+v", e)
+	}
+}
+
+// TestDefaultDirUsesXDGStateHome verifies that XDG_STATE_HOME is respected.
+func TestDefaultDirUsesXDGStateHome(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/xdg-state")
+
+	path, err := DefaultDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := filepath.Join("/tmp/xdg-state", "gitboard")
+	if path != expected {
+		t.Errorf("expected %q, got %q", expected, path)
+	}
+}
+
+// TestAccountDir verifies that each profile and user gets its own state, so
+// a run under one profile doesn't make the next profile's run incremental.
+func TestAccountDir(t *testing.T) {
+	base := t.TempDir()
+
+	tests := []struct {
+		profile  string
+		user     string
+		expected string
+	}{
+		{expected: base},
+		{profile: "work", expected: filepath.Join(base, "profiles", "work")},
+		{user: "octocat", expected: filepath.Join(base, "users", "octocat")},
+		{profile: "work", user: "octocat", expected: filepath.Join(base, "profiles", "work", "users", "octocat")},
+	}
+	for _, tt := range tests {
+		if got := AccountDir(base, tt.profile, tt.user); got != tt.expected {
+			t.Errorf("AccountDir(%q, %q): expected %q, got %q", tt.profile, tt.user, tt.expected, got)
+		}
+	}
+
+	personal, err := Load(filepath.Join(AccountDir(base, "personal", ""), "state.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	personal.LastRun = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := personal.Save(); err != nil {
+		t.Fatalf("unexpected error saving: %v", err)
+	}
+
+	work, err := Load(filepath.Join(AccountDir(base, "work", ""), "state.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !work.LastRun.IsZero() {
+		t.Errorf("expected the work profile to start without a LastRun, got %v", work.LastRun)
+	}
+}